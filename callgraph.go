@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+
+	callgraph "github.com/jha-naman/tree-tags/callgraph"
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+)
+
+// runCallgraph implements the 'callgraph' subcommand, printing the call graph
+// of the go files in the given files and directories.
+func runCallgraph(args []string) {
+	flagSet := flag.NewFlagSet("callgraph", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: tree-tags callgraph [options] [FILE|DIR...]")
+		flagSet.PrintDefaults()
+	}
+
+	var root, format, packageName string
+	var depth int
+	flagSet.StringVar(&root, "root", "", "only print the functions reachable from this function or method, named by the import path of its package, e.g. 'example.com/m.run' or 'example.com/m/server.Server.Start'")
+	flagSet.IntVar(&depth, "depth", 0, "with the 'root' option, only follow this many calls from the root, 0 for no limit")
+	flagSet.StringVar(&packageName, "package", "", "only print the functions and methods of the package with this import path")
+	flagSet.StringVar(&format, "format", "dot", "output format, one of 'dot' or 'json'")
+
+	paths := parseInterspersed(flagSet, subcommandArgs(flagSet, args))
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	if format != "dot" && format != "json" {
+		log.Fatalf("invalid value %q for 'format' option, should be one of 'dot' or 'json'", format)
+	}
+
+	if depth < 0 || (depth > 0 && root == "") {
+		log.Fatal("the 'depth' option needs the 'root' option and should not be negative")
+	}
+
+	var tags []common.TagEntry
+	for _, fileName := range goFileNames(paths) {
+		tags = append(tags, golang.GetFileTags(fileName, common.Options{References: true})...)
+	}
+
+	graph := callgraph.Build(tags, golang.FunctionKinds, golang.KindCallReference, packagePathFunc())
+	if packageName != "" {
+		graph = graph.Package(packageName)
+	}
+
+	if root != "" {
+		var err error
+		if graph, err = graph.Reachable(root, depth); err != nil {
+			log.Fatal(err.Error())
+		}
+	}
+
+	writer := bufio.NewWriter(os.Stdout)
+
+	var err error
+	if format == "json" {
+		err = callgraph.WriteJSON(writer, graph)
+	} else {
+		err = callgraph.WriteDOT(writer, graph)
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err != nil {
+		log.Fatal("error while writing call graph:", err.Error())
+	}
+}
+
+// goFileNames returns the go files given and the ones in the directories
+// given.
+func goFileNames(paths []string) []string {
+	var fileNames []string
+
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(filePath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() && filepath.Ext(filePath) == ".go" {
+				fileNames = append(fileNames, filepath.Clean(filePath))
+			}
+
+			return nil
+		})
+		if err != nil {
+			log.Fatal("error while listing files:", err.Error())
+		}
+	}
+
+	return fileNames
+}
+
+// packagePathFunc returns the function giving the import path of the package
+// of a file, from the module path of the 'go.mod' file in the current
+// directory and the directory of the file. Outside of modules the name of the
+// current directory takes the place of the module path.
+func packagePathFunc() func(fileName string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatal("error while getting the current directory:", err.Error())
+	}
+
+	modulePath, err := readModulePath("go.mod")
+	if err != nil {
+		modulePath = filepath.Base(wd)
+	}
+
+	return func(fileName string) string {
+		directory := filepath.Dir(fileName)
+		if absolute, err := filepath.Abs(directory); err == nil {
+			if relative, err := filepath.Rel(wd, absolute); err == nil {
+				directory = relative
+			}
+		}
+
+		return path.Join(modulePath, filepath.ToSlash(directory))
+	}
+}
@@ -0,0 +1,265 @@
+// Package callgraph builds a best effort call graph from the tags of go
+// files: the reference tags of calls, scoped by the function they are in, are
+// resolved by name to the tagged functions and methods. Calls like 'run()'
+// are resolved to the functions of the package of the caller, calls like
+// 'server.Run()' on an import to the functions of the imported package, and
+// other calls like 's.Run()' to methods. Without type information calls of
+// methods of the same name on different types cannot be told apart, so they
+// get an edge to each of them, and calls of functions which are not tagged,
+// like the ones of the standard library, are left out.
+package callgraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// Node is a function or method, named by the import path of its package, like
+// 'example.com/m.run' or 'example.com/m/server.Server.Start'.
+type Node struct {
+	Name    string `json:"name"`
+	Package string `json:"package"`
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+}
+
+// Edge is a call of the callee in the body of the caller.
+type Edge struct {
+	Caller string `json:"caller"`
+	Callee string `json:"callee"`
+}
+
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// candidate is a function or method calls can be resolved to.
+type candidate struct {
+	name, packagePath string
+	method            bool
+}
+
+// Build returns the call graph for the tags, which need to include the
+// reference tags. The function kinds are the letters of the kinds of the
+// tags of functions and methods, and the call kind the one of the reference
+// tags of calls. The package path returns the import path of the package of
+// a file, which keeps apart the packages of the same name in different
+// directories, like the 'main' packages of commands.
+func Build(tags []common.TagEntry, functionKinds []string, callKind string, packagePath func(fileName string) string) *Graph {
+	graph := &Graph{}
+	byName := map[string][]candidate{}
+	seen := map[string]bool{}
+
+	for _, tag := range tags {
+		if tag.ExtensionFields["roles"] != "" || !slices.Contains(functionKinds, tag.Kind) {
+			continue
+		}
+
+		packageName := packagePath(tag.FileName)
+		name, isMethod := functionName(tag, packageName)
+
+		// functions like 'init' can be declared more than once
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		line, _ := strconv.Atoi(tag.ExtensionFields["line"])
+		graph.Nodes = append(graph.Nodes, Node{Name: name, Package: packageName, File: tag.FileName, Line: line})
+		byName[tag.Name] = append(byName[tag.Name], candidate{name: name, packagePath: packageName, method: isMethod})
+	}
+
+	edges := map[Edge]bool{}
+	for _, tag := range tags {
+		scope := tag.ExtensionFields["func"]
+		if tag.ExtensionFields["roles"] != "ref" || tag.Kind != callKind || scope == "" || tag.ExtensionFields["local"] != "" {
+			continue
+		}
+
+		// the scope is named by the package clause, like 'main.run'
+		_, function, _ := strings.Cut(scope, ".")
+		packageName := packagePath(tag.FileName)
+		caller := packageName + "." + function
+
+		for _, callee := range resolve(byName[tag.Name], tag, packageName) {
+			edges[Edge{Caller: caller, Callee: callee}] = true
+		}
+	}
+
+	for edge := range edges {
+		graph.Edges = append(graph.Edges, edge)
+	}
+
+	graph.sort()
+
+	return graph
+}
+
+// functionName returns the name of the node for the tag of a function or
+// method, without the pointer and type parameters of the receiver type, and
+// whether it is a method.
+func functionName(tag common.TagEntry, packagePath string) (string, bool) {
+	if receiver, ok := tag.ExtensionFields["unkown"]; ok {
+		_, typeName, _ := strings.Cut(common.ReceiverTypeName(receiver), ".")
+		return packagePath + "." + typeName + "." + tag.Name, true
+	}
+
+	if tag.ExtensionFields["package"] == "" {
+		return "", false
+	}
+
+	return packagePath + "." + tag.Name, false
+}
+
+// resolve returns the names of the candidates a call can refer to: for calls
+// on an import the functions of the imported package, for other selected
+// names the methods and otherwise the functions of the package of the caller.
+func resolve(candidates []candidate, call common.TagEntry, callerPackage string) []string {
+	_, isSelected := call.ExtensionFields["qualifier"]
+	importPath, isImport := call.ExtensionFields["import"]
+
+	var names []string
+	for _, candidate := range candidates {
+		var matches bool
+		switch {
+		case isImport:
+			matches = !candidate.method && candidate.packagePath == importPath
+		case isSelected:
+			matches = candidate.method
+		default:
+			matches = !candidate.method && candidate.packagePath == callerPackage
+		}
+
+		if matches {
+			names = append(names, candidate.name)
+		}
+	}
+
+	return names
+}
+
+// Reachable returns the part of the graph reachable from the root, following
+// the calls up to the given depth, 0 for no limit.
+func (g *Graph) Reachable(root string, depth int) (*Graph, error) {
+	if !slices.ContainsFunc(g.Nodes, func(node Node) bool { return node.Name == root }) {
+		return nil, fmt.Errorf("no function or method named %q", root)
+	}
+
+	callees := map[string][]string{}
+	for _, edge := range g.Edges {
+		callees[edge.Caller] = append(callees[edge.Caller], edge.Callee)
+	}
+
+	reached := map[string]bool{root: true}
+	edges := map[Edge]bool{}
+	for level, current := 1, []string{root}; len(current) > 0 && (depth == 0 || level <= depth); level++ {
+		var next []string
+		for _, caller := range current {
+			for _, callee := range callees[caller] {
+				edges[Edge{Caller: caller, Callee: callee}] = true
+				if !reached[callee] {
+					reached[callee] = true
+					next = append(next, callee)
+				}
+			}
+		}
+
+		current = next
+	}
+
+	return g.subgraph(func(name string) bool { return reached[name] }, func(edge Edge) bool { return edges[edge] }), nil
+}
+
+// Package returns the part of the graph with the functions and methods of the
+// package, given by its import path, and the calls between them.
+func (g *Graph) Package(packagePath string) *Graph {
+	packages := map[string]string{}
+	for _, node := range g.Nodes {
+		packages[node.Name] = node.Package
+	}
+
+	inPackage := func(name string) bool { return packages[name] == packagePath }
+
+	return g.subgraph(inPackage, func(edge Edge) bool { return inPackage(edge.Caller) && inPackage(edge.Callee) })
+}
+
+func (g *Graph) subgraph(keepNode func(string) bool, keepEdge func(Edge) bool) *Graph {
+	subgraph := &Graph{}
+	for _, node := range g.Nodes {
+		if keepNode(node.Name) {
+			subgraph.Nodes = append(subgraph.Nodes, node)
+		}
+	}
+
+	for _, edge := range g.Edges {
+		if keepEdge(edge) {
+			subgraph.Edges = append(subgraph.Edges, edge)
+		}
+	}
+
+	return subgraph
+}
+
+func (g *Graph) sort() {
+	sort.SliceStable(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].Name < g.Nodes[j].Name
+	})
+
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].Caller != g.Edges[j].Caller {
+			return g.Edges[i].Caller < g.Edges[j].Caller
+		}
+
+		return g.Edges[i].Callee < g.Edges[j].Callee
+	})
+}
+
+// WriteDOT writes the graph in the graphviz dot format, to be rendered with
+// e.g. 'dot -Tsvg'.
+func WriteDOT(writer io.Writer, g *Graph) error {
+	if _, err := fmt.Fprintln(writer, "digraph callgraph {"); err != nil {
+		return err
+	}
+
+	for _, node := range g.Nodes {
+		if _, err := fmt.Fprintf(writer, "\t%s [tooltip=%s];\n", strconv.Quote(node.Name), strconv.Quote(node.File+":"+strconv.Itoa(node.Line))); err != nil {
+			return err
+		}
+	}
+
+	for _, edge := range g.Edges {
+		if _, err := fmt.Fprintf(writer, "\t%s -> %s;\n", strconv.Quote(edge.Caller), strconv.Quote(edge.Callee)); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(writer, "}")
+
+	return err
+}
+
+// WriteJSON writes the graph as a json object with the 'nodes' and 'edges'
+// lists.
+func WriteJSON(writer io.Writer, g *Graph) error {
+	output := *g
+	if output.Nodes == nil {
+		output.Nodes = []Node{}
+	}
+
+	if output.Edges == nil {
+		output.Edges = []Edge{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(output)
+}
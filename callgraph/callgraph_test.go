@@ -0,0 +1,141 @@
+package callgraph
+
+import (
+	"bytes"
+	"path"
+	"testing"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+// packagePath names the packages of the test files like the ones of the
+// 'example.com/m' module.
+func packagePath(fileName string) string {
+	return path.Join("example.com/m", path.Dir(fileName))
+}
+
+func fileTags(files map[string]string) []common.TagEntry {
+	var tags []common.TagEntry
+	for _, fileName := range []string{"main.go", "cmd/a/main.go", "cmd/b/main.go", "server/server.go"} {
+		if source, ok := files[fileName]; ok {
+			p := golang.NewProcessorFromBytes(fileName, []byte(source), common.Options{References: true})
+			tags = append(tags, p.GetTags()...)
+		}
+	}
+
+	return tags
+}
+
+func TestBuild(t *testing.T) {
+	input := `package main
+
+type Server struct{}
+
+func (s *Server) Start() {
+	listen()
+	fmt.Println()
+}
+
+func listen() {
+	accept()
+}
+
+func accept() {}
+
+func main() {
+	s := &Server{}
+	s.Start()
+	s.Start()
+}
+`
+
+	graph := Build(fileTags(map[string]string{"main.go": input}), golang.FunctionKinds, golang.KindCallReference, packagePath)
+
+	assert.Equal(t, []Node{
+		{Name: "example.com/m.Server.Start", Package: "example.com/m", File: "main.go", Line: 5},
+		{Name: "example.com/m.accept", Package: "example.com/m", File: "main.go", Line: 14},
+		{Name: "example.com/m.listen", Package: "example.com/m", File: "main.go", Line: 10},
+		{Name: "example.com/m.main", Package: "example.com/m", File: "main.go", Line: 16},
+	}, graph.Nodes)
+	assert.Equal(t, []Edge{
+		{Caller: "example.com/m.Server.Start", Callee: "example.com/m.listen"},
+		{Caller: "example.com/m.listen", Callee: "example.com/m.accept"},
+		{Caller: "example.com/m.main", Callee: "example.com/m.Server.Start"},
+	}, graph.Edges)
+
+	reachable, err := graph.Reachable("example.com/m.main", 2)
+	assert.NoError(t, err)
+
+	var dot bytes.Buffer
+	assert.NoError(t, WriteDOT(&dot, reachable))
+	assert.Equal(t, `digraph callgraph {
+	"example.com/m.Server.Start" [tooltip="main.go:5"];
+	"example.com/m.listen" [tooltip="main.go:10"];
+	"example.com/m.main" [tooltip="main.go:16"];
+	"example.com/m.Server.Start" -> "example.com/m.listen";
+	"example.com/m.main" -> "example.com/m.Server.Start";
+}
+`, dot.String())
+
+	_, err = graph.Reachable("example.com/m.missing", 0)
+	assert.Error(t, err)
+
+	assert.Empty(t, graph.Package("example.com/m/other").Nodes)
+}
+
+func TestBuildPackages(t *testing.T) {
+	graph := Build(fileTags(map[string]string{
+		"cmd/a/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/m/server"
+)
+
+func main() {
+	fmt.Println()
+	server.Run()
+	run := func() {}
+	run()
+}
+`,
+		"cmd/b/main.go": `package main
+
+import srv "example.com/m/server"
+
+func main() {
+	srv.Run()
+	run()
+}
+
+func run() {}
+`,
+		"server/server.go": `package server
+
+func Run() {}
+
+func run() {}
+`,
+	}), golang.FunctionKinds, golang.KindCallReference, packagePath)
+
+	// the main packages of the commands are kept apart, the qualified calls
+	// only go to the imported package, and 'fmt', which is not tagged, and
+	// the local 'run' function value are left out
+	assert.Equal(t, []Node{
+		{Name: "example.com/m/cmd/a.main", Package: "example.com/m/cmd/a", File: "cmd/a/main.go", Line: 9},
+		{Name: "example.com/m/cmd/b.main", Package: "example.com/m/cmd/b", File: "cmd/b/main.go", Line: 5},
+		{Name: "example.com/m/cmd/b.run", Package: "example.com/m/cmd/b", File: "cmd/b/main.go", Line: 10},
+		{Name: "example.com/m/server.Run", Package: "example.com/m/server", File: "server/server.go", Line: 3},
+		{Name: "example.com/m/server.run", Package: "example.com/m/server", File: "server/server.go", Line: 5},
+	}, graph.Nodes)
+	assert.Equal(t, []Edge{
+		{Caller: "example.com/m/cmd/a.main", Callee: "example.com/m/server.Run"},
+		{Caller: "example.com/m/cmd/b.main", Callee: "example.com/m/cmd/b.run"},
+		{Caller: "example.com/m/cmd/b.main", Callee: "example.com/m/server.Run"},
+	}, graph.Edges)
+
+	assert.Len(t, graph.Package("example.com/m/cmd/b").Nodes, 2)
+}
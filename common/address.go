@@ -0,0 +1,52 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var charsEscapeRegex = regexp.MustCompile("([$/\\\\])")
+var replaceRegex = []byte("\\${1}")
+
+// PatternAddress returns the search pattern address for the line. Lines
+// longer than the limit are cut off, without the '$' anchor, the same as
+// u-ctags does. A limit of 0 means no limit.
+func PatternAddress(line []byte, limit int) string {
+	endAnchor := "$"
+	if limit > 0 && len(line) > limit {
+		// do not cut a multi byte character in half
+		for limit > 0 && !utf8.RuneStart(line[limit]) {
+			limit--
+		}
+		line, endAnchor = line[:limit], ""
+	}
+
+	return fmt.Sprintf("/^%s%s/%s", string(charsEscapeRegex.ReplaceAll(line, replaceRegex)), endAnchor, ";\"")
+}
+
+// PatternText returns the line a tag pattern like '/^func main() {$/;"'
+// matches, also when it follows a line number like in '12;/^func main$/;"'.
+func PatternText(address string) string {
+	pattern := strings.TrimSuffix(address, ";\"")
+	if number, search, found := strings.Cut(pattern, ";"); found && number != "" && strings.Trim(number, "0123456789") == "" {
+		pattern = search
+	}
+
+	if len(pattern) < 2 || pattern[0] != '/' || pattern[len(pattern)-1] != '/' {
+		return ""
+	}
+
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern[1:len(pattern)-1], "^"), "$")
+
+	var text strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] == '\\' && i+1 < len(pattern) {
+			i++
+		}
+		text.WriteByte(pattern[i])
+	}
+
+	return text.String()
+}
@@ -0,0 +1,49 @@
+package common
+
+import "maps"
+
+// Field describes an extension field of the tags. Fields without a letter can
+// only be selected by their name, e.g. '--fields=-{doc}'. Keys are the names
+// the field is written under, the scope field for example is written as the
+// kind of the scope, like 'struct:main.T'.
+type Field struct {
+	Letter, Name, Description string
+	Keys                      []string
+	DisabledByDefault         bool
+}
+
+// DefaultFields returns the names of the fields which are enabled by default.
+func DefaultFields(fields []Field) map[string]bool {
+	enabled := map[string]bool{}
+	for _, field := range fields {
+		if !field.DisabledByDefault {
+			enabled[field.Name] = true
+		}
+	}
+
+	return enabled
+}
+
+// WithEnabledFields returns a copy of the tag without the extension fields
+// which are not enabled, leaving the fields of the tag itself as they are.
+// Extension fields not described by any of the fields are kept. The full doc
+// comment is dropped along with the 'doc' extension field.
+func (t TagEntry) WithEnabledFields(fields []Field, enabled map[string]bool) TagEntry {
+	t.ExtensionFields = maps.Clone(t.ExtensionFields)
+
+	for _, field := range fields {
+		if enabled[field.Name] {
+			continue
+		}
+
+		for _, key := range field.Keys {
+			delete(t.ExtensionFields, key)
+
+			if key == "doc" {
+				t.Doc = ""
+			}
+		}
+	}
+
+	return t
+}
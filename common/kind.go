@@ -0,0 +1,31 @@
+package common
+
+// Kind describes a kind of tag, the one letter name is what goes in the tags
+// file while the long name is used in the human readable and json outputs.
+type Kind struct {
+	Letter, Name, Description string
+}
+
+// KindName returns the long name of the kind with the given letter, or the
+// letter itself for kinds not in the list.
+func KindName(kinds []Kind, letter string) string {
+	for _, kind := range kinds {
+		if kind.Letter == letter {
+			return kind.Name
+		}
+	}
+
+	return letter
+}
+
+// KindLetter returns the letter of the kind with the given long name, or the
+// name itself for kinds not in the list.
+func KindLetter(kinds []Kind, name string) string {
+	for _, kind := range kinds {
+		if kind.Name == name {
+			return kind.Letter
+		}
+	}
+
+	return name
+}
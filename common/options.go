@@ -1,5 +1,64 @@
 package common
 
+const (
+	GeneratedInclude  = "include"
+	GeneratedExclude  = "exclude"
+	GeneratedSeparate = "separate"
+)
+
+const (
+	TestsInclude = "include"
+	TestsExclude = "exclude"
+	TestsOnly    = "only"
+)
+
+const (
+	OutputFormatUCtags = "u-ctags"
+	OutputFormatJSON   = "json"
+	OutputFormatSQLite = "sqlite"
+	OutputFormatGtags  = "gtags"
+	OutputFormatXref   = "xref"
+)
+
 type Options struct {
-	AppendMode bool
+	AppendMode       bool
+	Prune            bool
+	FilesFrom        string
+	Generated        string
+	OutputFormat     string
+	FullDoc          bool
+	StructTagAliases bool
+	ExportedOnly     bool
+	Tests            string
+	Stdin            bool
+	StdinFileName    string
+	// PatternLengthLimit is the number of bytes of the line after which the
+	// search patterns are cut off, 0 means no limit.
+	PatternLengthLimit int
+	Sort               string
+	// TagsQueries are the sources of tags queries for go files, adding tags
+	// for definitions the built in extraction does not cover.
+	TagsQueries []string
+	// RegexDefinitions are the definitions of the '--regex-<language>'
+	// options.
+	RegexDefinitions []RegexDefinition
+	// Kinds are the names of the kinds of tags written for go files and
+	// Fields the names of the extension fields written, nil for the defaults.
+	Kinds  map[string]bool
+	Fields map[string]bool
+	// References adds reference tags, having the 'roles:ref' field, for the
+	// uses of identifiers in function bodies.
+	References bool
+	// Filter reads file names from stdin and writes their tags to stdout,
+	// each file followed by the FilterTerminator.
+	Filter           bool
+	FilterTerminator string
+	// XrefFormat is the format of the lines of the xref output format.
+	XrefFormat XrefFormat
+	// SourceLines sets the SourceLine of the tags, for the output formats
+	// printing the source lines of the tags.
+	SourceLines bool
+	// Columns sets the Column of the tags, for the ranges of the names in the
+	// SCIP index.
+	Columns bool
 }
@@ -0,0 +1,177 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// RegexDefinition is a u-ctags style regex definition, given as
+//
+//	--regex-<language>=/regexp/replacement/[kind-spec/][flags]
+//
+// Every line of the files of the language matching the regexp gets a tag
+// named by the replacement, in which '\1' to '\9' refer to the groups of the
+// match. The kind spec is 'letter[,name[,description]]' and the only flag is
+// 'i', for case insensitive matching. The language is 'go' or a file name
+// extension, so that files without a grammar can be tagged too.
+type RegexDefinition struct {
+	Language    string
+	Regex       *regexp.Regexp
+	Replacement string
+	Kind        Kind
+}
+
+// defaultRegexKind is the kind of the tags of definitions without a kind spec.
+var defaultRegexKind = Kind{Letter: "r", Name: "regex", Description: "regex matches"}
+
+var groupReferenceRegex = regexp.MustCompile(`\\(\d)`)
+
+func ParseRegexDefinition(language, value string) (RegexDefinition, error) {
+	if language == "" {
+		return RegexDefinition{}, errors.New("missing language")
+	}
+
+	if value == "" {
+		return RegexDefinition{}, errors.New("empty regex definition")
+	}
+
+	parts := splitRegexDefinition(value[1:], value[0])
+	if len(parts) < 3 || len(parts) > 4 {
+		return RegexDefinition{}, fmt.Errorf("%q should be like /regexp/replacement/[kind-spec/][flags]", value)
+	}
+
+	// the last part holds the flags, after the kind spec if there is one
+	pattern, replacement, kindSpec, flags := parts[0], parts[1], "", parts[len(parts)-1]
+	if len(parts) == 4 {
+		kindSpec = parts[2]
+	}
+
+	if pattern == "" || replacement == "" {
+		return RegexDefinition{}, fmt.Errorf("%q should have a regexp and a replacement", value)
+	}
+
+	for _, flag := range flags {
+		if flag != 'i' {
+			return RegexDefinition{}, fmt.Errorf("unknown flag %q, only 'i' is supported", flag)
+		}
+		pattern = "(?i)" + pattern
+	}
+
+	regex, err := regexp.Compile(pattern)
+	if err != nil {
+		return RegexDefinition{}, err
+	}
+
+	for _, match := range groupReferenceRegex.FindAllStringSubmatch(replacement, -1) {
+		if group, _ := strconv.Atoi(match[1]); group > regex.NumSubexp() {
+			return RegexDefinition{}, fmt.Errorf("replacement refers to group %d, the regexp only has %d", group, regex.NumSubexp())
+		}
+	}
+
+	kind, err := parseKindSpec(kindSpec)
+	if err != nil {
+		return RegexDefinition{}, err
+	}
+
+	return RegexDefinition{Language: language, Regex: regex, Replacement: replacement, Kind: kind}, nil
+}
+
+// splitRegexDefinition splits the definition at the separators, which can be
+// escaped with a backslash.
+func splitRegexDefinition(value string, separator byte) []string {
+	var parts []string
+	var part strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && i+1 < len(value) && value[i+1] == separator:
+			part.WriteByte(separator)
+			i++
+		case value[i] == separator:
+			parts = append(parts, part.String())
+			part.Reset()
+		default:
+			part.WriteByte(value[i])
+		}
+	}
+
+	return append(parts, part.String())
+}
+
+func parseKindSpec(kindSpec string) (Kind, error) {
+	if kindSpec == "" {
+		return defaultRegexKind, nil
+	}
+
+	fields := strings.SplitN(kindSpec, ",", 3)
+	kind := Kind{Letter: fields[0], Name: defaultRegexKind.Name}
+	if len(kind.Letter) != 1 {
+		return Kind{}, fmt.Errorf("kind letter %q should be a single letter", kind.Letter)
+	}
+
+	if len(fields) > 1 && fields[1] != "" {
+		kind.Name = fields[1]
+	}
+
+	if len(fields) > 2 {
+		kind.Description = fields[2]
+	}
+
+	return kind, nil
+}
+
+// AppliesTo reports whether the definition is for files like the given one.
+func (d RegexDefinition) AppliesTo(fileName string) bool {
+	return strings.TrimPrefix(filepath.Ext(fileName), ".") == d.Language
+}
+
+// RegexTags returns the tags for the lines of the file matching the regex
+// definitions of the options which apply to it. With the Columns option the
+// tags get the column of the first group matching their name.
+func RegexTags(fileName string, lines [][]byte, options Options) []TagEntry {
+	var tags []TagEntry
+
+	for _, definition := range options.RegexDefinitions {
+		if !definition.AppliesTo(fileName) {
+			continue
+		}
+
+		template := groupReferenceRegex.ReplaceAllString(strings.ReplaceAll(definition.Replacement, "$", "$$"), "${$1}")
+		for i, line := range lines {
+			match := definition.Regex.FindSubmatchIndex(line)
+			if match == nil {
+				continue
+			}
+
+			name := string(definition.Regex.Expand(nil, []byte(template), line, match))
+			if name == "" {
+				continue
+			}
+
+			tag := TagEntry{
+				Name:            name,
+				FileName:        fileName,
+				Address:         PatternAddress(line, options.PatternLengthLimit),
+				Kind:            definition.Kind.Letter,
+				ExtensionFields: map[string]string{"line": strconv.Itoa(i + 1)},
+			}
+
+			if options.Columns {
+				for group := 2; group < len(match); group += 2 {
+					if match[group] >= 0 && string(line[match[group]:match[group+1]]) == name {
+						tag.Column = match[group] + 1
+						break
+					}
+				}
+			}
+
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
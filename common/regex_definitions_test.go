@@ -0,0 +1,76 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRegexDefinition(t *testing.T) {
+	tests := []struct {
+		value        string
+		expectedKind Kind
+		expectedErr  bool
+	}{
+		{value: `/ROUTE: (\S+)/\1/`, expectedKind: defaultRegexKind},
+		{value: `/ROUTE: (\S+)/\1/r,route/`, expectedKind: Kind{Letter: "r", Name: "route"}},
+		{value: `/ROUTE: (\S+)/\1/r,route,http routes/i`, expectedKind: Kind{Letter: "r", Name: "route", Description: "http routes"}},
+		{value: `/ROUTE: (\S+)/\1/i`, expectedKind: defaultRegexKind},
+		{value: `|^\s*- (\w+)|\1|k|`, expectedKind: Kind{Letter: "k", Name: "regex"}},
+		{value: `/ROUTE: (\S+)/\1`, expectedErr: true},
+		{value: `/ROUTE: (\S+)/\2/`, expectedErr: true},
+		{value: `/ROUTE: (\S+)//`, expectedErr: true},
+		{value: `/ROUTE: (/\1/`, expectedErr: true},
+		{value: `/ROUTE: (\S+)/\1/route/`, expectedErr: true},
+		{value: `/ROUTE: (\S+)/\1/r/x`, expectedErr: true},
+		{value: ``, expectedErr: true},
+	}
+
+	for _, test := range tests {
+		definition, err := ParseRegexDefinition("go", test.value)
+		if test.expectedErr {
+			assert.Error(t, err, test.value)
+			continue
+		}
+
+		assert.NoError(t, err, test.value)
+		assert.Equal(t, test.expectedKind, definition.Kind, test.value)
+	}
+}
+
+func TestRegexTags(t *testing.T) {
+	routes, err := ParseRegexDefinition("go", `/\/\/ ROUTE: \/api\/(\S+)/api_\1/r,route/`)
+	assert.NoError(t, err)
+
+	handlers, err := ParseRegexDefinition("go", `/router\.handle\("([^"]+)"/\1/h,handler/i`)
+	assert.NoError(t, err)
+
+	sections, err := ParseRegexDefinition("md", `/^#+ (.+)$/\1/s,section/`)
+	assert.NoError(t, err)
+
+	lines := [][]byte{
+		[]byte("// ROUTE: /api/users"),
+		[]byte(`Router.Handle("users", usersHandler)`),
+		[]byte("# not markdown"),
+	}
+
+	options := Options{RegexDefinitions: []RegexDefinition{routes, handlers, sections}}
+	tags := RegexTags("main.go", lines, options)
+	assert.Equal(t, []TagEntry{
+		{Name: "api_users", FileName: "main.go", Address: `/^\/\/ ROUTE: \/api\/users$/;"`, Kind: "r", ExtensionFields: map[string]string{"line": "1"}},
+		{Name: "users", FileName: "main.go", Address: `/^Router.Handle("users", usersHandler)$/;"`, Kind: "h", ExtensionFields: map[string]string{"line": "2"}},
+	}, tags)
+
+	tags = RegexTags("README.md", lines, options)
+	assert.Equal(t, []TagEntry{
+		{Name: "not markdown", FileName: "README.md", Address: `/^# not markdown$/;"`, Kind: "s", ExtensionFields: map[string]string{"line": "3"}},
+	}, tags)
+
+	// the names put together from the line have no column
+	options.Columns = true
+	var columns []int
+	for _, tag := range RegexTags("main.go", lines, options) {
+		columns = append(columns, tag.Column)
+	}
+	assert.Equal(t, []int{0, 16}, columns)
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ApplySelection updates the set of enabled names, of kinds or fields, for a
+// u-ctags style selection. The letters map the letters to the names. A
+// selection not starting with '+' or '-', like 'fv', enables only the given
+// ones, '+f-v' enables 'f' and disables 'v', '*' stands for all of them and
+// '{name}' selects by name.
+func ApplySelection(enabled map[string]bool, selection string, letters map[string]string, names []string) error {
+	enable := true
+	if !strings.HasPrefix(selection, "+") && !strings.HasPrefix(selection, "-") {
+		clear(enabled)
+	}
+
+	for i := 0; i < len(selection); i++ {
+		var name string
+
+		switch c := selection[i]; c {
+		case '+', '-':
+			enable = c == '+'
+			continue
+		case '*':
+			for _, name := range names {
+				enabled[name] = enable
+			}
+			continue
+		case '{':
+			end := strings.IndexByte(selection[i:], '}')
+			if end < 0 {
+				return fmt.Errorf("missing '}' in %q", selection)
+			}
+
+			name = selection[i+1 : i+end]
+			i += end
+
+			if !slices.Contains(names, name) {
+				return fmt.Errorf("unknown name %q", name)
+			}
+		default:
+			var ok bool
+			if name, ok = letters[string(c)]; !ok {
+				return fmt.Errorf("unknown letter %q", c)
+			}
+		}
+
+		enabled[name] = enable
+	}
+
+	return nil
+}
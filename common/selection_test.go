@@ -0,0 +1,63 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplySelection(t *testing.T) {
+	letters := map[string]string{"f": "func", "v": "var", "c": "const"}
+	names := []string{"func", "var", "const", "doc"}
+
+	tests := []struct {
+		selection       string
+		expectedEnabled map[string]bool
+		expectedErr     bool
+	}{
+		{selection: "+c-v", expectedEnabled: map[string]bool{"func": true, "var": false, "const": true}},
+		{selection: "fc", expectedEnabled: map[string]bool{"func": true, "const": true}},
+		{selection: "-*", expectedEnabled: map[string]bool{"func": false, "var": false, "const": false, "doc": false}},
+		{selection: "*-{doc}", expectedEnabled: map[string]bool{"func": true, "var": true, "const": true, "doc": false}},
+		{selection: "+{doc}f", expectedEnabled: map[string]bool{"func": true, "var": true, "doc": true}},
+		{selection: "+x", expectedErr: true},
+		{selection: "+{nope}", expectedErr: true},
+		{selection: "+{doc", expectedErr: true},
+	}
+
+	for _, test := range tests {
+		enabled := map[string]bool{"func": true, "var": true}
+		err := ApplySelection(enabled, test.selection, letters, names)
+		if test.expectedErr {
+			assert.Error(t, err, test.selection)
+			continue
+		}
+
+		assert.NoError(t, err, test.selection)
+		assert.Equal(t, test.expectedEnabled, enabled, test.selection)
+	}
+}
+
+func TestWithEnabledFields(t *testing.T) {
+	fields := []Field{
+		{Letter: "s", Name: "scope", Keys: []string{"package", "struct"}},
+		{Letter: "S", Name: "signature", Keys: []string{"signature"}, DisabledByDefault: true},
+		{Name: "doc", Keys: []string{"doc"}},
+	}
+
+	tag := TagEntry{Name: "f", ExtensionFields: map[string]string{"struct": "main.T", "signature": "()", "doc": "F.", "other": "x"}, Doc: "F.\n\nMore."}
+	enabled := DefaultFields(fields)
+	assert.Equal(t, map[string]bool{"scope": true, "doc": true}, enabled)
+	assert.Equal(t, tag.Doc, tag.WithEnabledFields(fields, enabled).Doc)
+
+	// the full doc comment of the json output goes with the doc field
+	enabled["doc"] = false
+	assert.Equal(t, map[string]string{"struct": "main.T", "other": "x"}, tag.WithEnabledFields(fields, enabled).ExtensionFields)
+	assert.Equal(t, "", tag.WithEnabledFields(fields, enabled).Doc)
+
+	// the fields of the tag itself are left for later uses, e.g. the scopes
+	// implementations are found by
+	enabled["scope"] = false
+	assert.Equal(t, map[string]string{"other": "x"}, tag.WithEnabledFields(fields, enabled).ExtensionFields)
+	assert.Equal(t, map[string]string{"struct": "main.T", "signature": "()", "doc": "F.", "other": "x"}, tag.ExtensionFields)
+}
@@ -0,0 +1,80 @@
+package common
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	SortYes      = "yes"
+	SortNo       = "no"
+	SortFoldcase = "foldcase"
+)
+
+// SortedPseudoTagValue returns the value of the '!_TAG_FILE_SORTED' pseudo
+// tag for the sort mode: 0 for unsorted, 1 for sorted and 2 for foldcase.
+func SortedPseudoTagValue(sortMode string) string {
+	switch sortMode {
+	case SortNo:
+		return "0"
+	case SortFoldcase:
+		return "2"
+	default:
+		return "1"
+	}
+}
+
+// SortTags sorts the tags by name, file name and line, comparing the names
+// case insensitively in 'foldcase' mode. The order of the tags is kept in 'no'
+// mode.
+func SortTags(tags []TagEntry, sortMode string) {
+	if sortMode == SortNo {
+		return
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+
+		if sortMode == SortFoldcase {
+			if foldedA, foldedB := FoldCase(a.Name), FoldCase(b.Name); foldedA != foldedB {
+				return foldedA < foldedB
+			}
+		}
+
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+
+		if a.FileName != b.FileName {
+			return a.FileName < b.FileName
+		}
+
+		return a.lineNumber() < b.lineNumber()
+	})
+}
+
+// FoldCase upper cases the ascii letters of the name, the way u-ctags and
+// readtags compare names in foldcase sorted tags files.
+func FoldCase(name string) string {
+	folded := []byte(name)
+	for i, c := range folded {
+		if c >= 'a' && c <= 'z' {
+			folded[i] = c - 'a' + 'A'
+		}
+	}
+
+	return string(folded)
+}
+
+// lineNumber returns the line of the tag from its 'line' field, or from its
+// address when that starts with a line number, and 0 when it is not known.
+func (t TagEntry) lineNumber() int {
+	line, err := strconv.Atoi(t.ExtensionFields["line"])
+	if err != nil {
+		number, _, _ := strings.Cut(strings.TrimSuffix(t.Address, `;"`), ";")
+		line, _ = strconv.Atoi(number)
+	}
+
+	return line
+}
@@ -0,0 +1,60 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortTags(t *testing.T) {
+	tag := func(name, fileName, line string) TagEntry {
+		return TagEntry{Name: name, FileName: fileName, ExtensionFields: map[string]string{"line": line}}
+	}
+
+	tags := []TagEntry{
+		tag("b", "b.go", "3"),
+		tag("B", "a.go", "1"),
+		tag("a", "b.go", "10"),
+		tag("a", "b.go", "9"),
+		tag("a", "a.go", "20"),
+		tag("A", "c.go", "1"),
+	}
+
+	tests := []struct {
+		sortMode     string
+		expectedTags []TagEntry
+	}{
+		{SortNo, []TagEntry{tags[0], tags[1], tags[2], tags[3], tags[4], tags[5]}},
+		{SortYes, []TagEntry{tags[5], tags[1], tags[4], tags[3], tags[2], tags[0]}},
+		{SortFoldcase, []TagEntry{tags[5], tags[4], tags[3], tags[2], tags[1], tags[0]}},
+	}
+
+	for _, test := range tests {
+		sortedTags := append([]TagEntry{}, tags...)
+		SortTags(sortedTags, test.sortMode)
+		assert.Equal(t, test.expectedTags, sortedTags, test.sortMode)
+	}
+}
+
+func TestTagBytesFieldOrder(t *testing.T) {
+	tag := TagEntry{
+		Name:     "Name",
+		FileName: "a.go",
+		Address:  `/^	Name string$/;"`,
+		Kind:     "m",
+		ExtensionFields: map[string]string{
+			"jsontag":          "name",
+			"end":              "3",
+			"access":           "public",
+			"typeref:typename": "string",
+			"struct":           "main.T",
+			"line":             "3",
+			"doc":              "Name of T.",
+		},
+	}
+
+	expected := "Name\ta.go\t/^\tName string$/;\"\tm\tline:3\tstruct:main.T\ttyperef:typename:string\taccess:public\tend:3\tdoc:Name of T.\tjsontag:name"
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, expected, string(tag.Bytes()))
+	}
+}
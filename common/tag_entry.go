@@ -1,46 +1,121 @@
 package common
 
 import (
+	"encoding/json"
 	"fmt"
-	"log"
+	"slices"
+	"sort"
 	"strings"
 )
 
 type TagEntry struct {
 	Name, FileName, Address, Kind string
 	ExtensionFields               map[string]string
+	// Doc is the full text of the doc comment of the tagged declaration. It
+	// does not fit on a tag line, only the json output includes it.
+	Doc string
+	// SourceLine is the line of the tag in the source, without the cut off of
+	// the pattern length limit, for the source lines of the xref and gtags
+	// outputs. It is only set with the SourceLines option.
+	SourceLine string
+	// Column is the 1 based byte offset of the name of the tag in its line,
+	// or 0 when the name is not in the line, like the names regex definitions
+	// put together. It is only set with the Columns option.
+	Column int
 }
 
+// fieldOrder is the order the extension fields are written in, so that the
+// tags files do not change between runs. Fields not listed here come after
+// these, sorted by name.
+var fieldOrder = []string{"line", "package", "struct", "interface", "unkown", "func", "signature", "typeref:typename", "access", "roles", "end"}
+
+// ExtensionFieldNames returns the names of the extension fields of the tag in
+// the order they are written in.
+func (t TagEntry) ExtensionFieldNames() []string {
+	names := make([]string, 0, len(t.ExtensionFields))
+	for name := range t.ExtensionFields {
+		names = append(names, name)
+	}
+
+	rank := func(name string) int {
+		if i := slices.Index(fieldOrder, name); i >= 0 {
+			return i
+		}
+		return len(fieldOrder)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if rankI, rankJ := rank(names[i]), rank(names[j]); rankI != rankJ {
+			return rankI < rankJ
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// Bytes returns the tag as a line of a tags file. Tabs, new lines and
+// backslashes in the extension field values are escaped, so that the line
+// parses back into the same tag with TagFromString.
 func (t TagEntry) Bytes() []byte {
 	tagFields := []string{
 		t.Name,
 		t.FileName,
 		t.Address,
-		t.Kind,
 	}
 
-	for k, v := range t.ExtensionFields {
-		tagFields = append(tagFields, fmt.Sprintf("%s:%s", k, v))
+	if t.Kind != "" {
+		tagFields = append(tagFields, t.Kind)
+	}
+
+	for _, k := range t.ExtensionFieldNames() {
+		tagFields = append(tagFields, fmt.Sprintf("%s:%s", k, fieldValueEscaper.Replace(t.ExtensionFields[k])))
 	}
 
 	return []byte(strings.Join(tagFields, "\t"))
 }
 
-var allowedFieldNames = []string{"Name", "FileName", "Address", "Kind", "ExtensionFields"}
-
-func (t *TagEntry) SetFieldByName(fieldName string, value interface{}) {
-	switch fieldName {
-	case "Name":
-		t.Name = value.(string)
-	case "FileName":
-		t.FileName = value.(string)
-	case "Address":
-		t.Address = value.(string)
-	case "Kind":
-		t.Kind = value.(string)
-	case "ExtensionFields":
-		t.ExtensionFields = value.(map[string]string)
-	default:
-		log.Fatalf("invalid field name %s. should be one of %v", fieldName, allowedFieldNames)
+var fieldValueEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\r", `\r`, "\n", `\n`)
+
+// JSONBytes returns the tag in the json format u-ctags uses for its
+// '--output-format=json' option, with the long name of the kind. Extension
+// fields like 'typeref:typename' are split into the 'typeref' key and the
+// 'typename:...' value.
+func (t TagEntry) JSONBytes(fullDoc bool, kinds []Kind) ([]byte, error) {
+	jsonFields := map[string]string{
+		"_type":   "tag",
+		"name":    t.Name,
+		"path":    t.FileName,
+		"pattern": strings.TrimSuffix(t.Address, ";\""),
+		"kind":    KindName(kinds, t.Kind),
+	}
+
+	for k, v := range t.ExtensionFields {
+		if key, valuePrefix, found := strings.Cut(k, ":"); found {
+			k, v = key, fmt.Sprintf("%s:%s", valuePrefix, v)
+		}
+		jsonFields[k] = v
+	}
+
+	if fullDoc && t.Doc != "" {
+		jsonFields["doc"] = t.Doc
 	}
+
+	return json.Marshal(jsonFields)
+}
+
+// GtagsBytes returns the tag as a line of the GNU GLOBAL plug-in parser
+// protocol, in the GtagsXrefFormat: 'D' for definitions or 'R' for
+// references, the name, line number, file name and the source line with the
+// whitespace squeezed.
+func (t TagEntry) GtagsBytes() []byte {
+	return gtagsFormat.Bytes(t, nil)
+}
+
+// ReceiverTypeName returns the name of the receiver type in the scope of a
+// method, without the pointer and the type parameters, e.g. 'main.G' for
+// 'main.*G[T]'.
+func ReceiverTypeName(receiver string) string {
+	typeName, _, _ := strings.Cut(strings.Replace(receiver, "*", "", 1), "[")
+	return typeName
 }
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGtagsBytes(t *testing.T) {
+	definition := TagEntry{Name: "main", FileName: "main.go", Address: `/^func main() {$/;"`, Kind: "f", ExtensionFields: map[string]string{"line": "12"}}
+	assert.Equal(t, "D main               12 main.go          func main() {", string(definition.GtagsBytes()))
+
+	reference := TagEntry{Name: "run", FileName: "main.go", Address: `/^	run(a\/b)$/;"`, Kind: "f", ExtensionFields: map[string]string{"line": "13", "roles": "ref"}}
+	assert.Equal(t, "R run                13 main.go          run(a/b)", string(reference.GtagsBytes()))
+
+	definition.Address = PatternAddress([]byte("func main(args []string) {"), 10)
+	definition.SourceLine = "func main(args []string) {"
+	assert.Equal(t, "D main               12 main.go          func main(args []string) {", string(definition.GtagsBytes()))
+}
+
+func TestPatternText(t *testing.T) {
+	assert.Equal(t, `x := "a/b\c$"`, PatternText(PatternAddress([]byte(`x := "a/b\c$"`), 0)))
+	assert.Equal(t, "func f(", PatternText(PatternAddress([]byte("func f(a int)"), 7)))
+	assert.Equal(t, "", PatternText("12;\""))
+	assert.Equal(t, "func main", PatternText(`12;/^func main$/;"`))
+}
@@ -2,79 +2,191 @@ package common
 
 import (
 	"errors"
+	"fmt"
 	"strings"
 )
 
 var ErrStringIsAComment = errors.New("cannot create tag for a comment")
 
+// ParseError is returned for lines which are not valid tags(5) lines. Column
+// is the 1-based byte offset in the line at which parsing failed.
+type ParseError struct {
+	Column  int
+	Message string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Message)
+}
+
+// TagFromString parses a line of a tags file in the u-ctags flavour of the
+// tags(5) format:
+//
+//	{tagname}<TAB>{tagfile}<TAB>{tagaddress}[;"<TAB>{tagfield}...]
+//
+// The address is a line number or a /pattern/ or ?pattern? search command,
+// in which the delimiter and backslashes are escaped with a backslash, or a
+// line number and a search command separated by ';'. The
+// first field without a colon is the kind, the others are 'key:value' pairs
+// whose values may be empty and escape '\t', '\r', '\n' and '\\'. The address
+// of the tag always ends with ';"', as this is how tags are written back.
 func TagFromString(text string) (TagEntry, error) {
 	if strings.HasPrefix(text, "!_TAG_") {
 		return TagEntry{}, ErrStringIsAComment
 	}
 
-	fields := []string{"Name", "FileName", "Address", "Kind", "ExtensionFields"}
-	fieldIndex := 0
 	tag := TagEntry{}
-	var theOneBeforeChar, previousChar rune
-	var fieldAggregator string
-	var extensionFields = make(map[string]string)
-
-	for _, runeValue := range text {
-		switch fields[fieldIndex] {
-		case "Name", "FileName", "Kind":
-			if runeValue != '\t' {
-				fieldAggregator += string(runeValue)
-				continue
-			}
-
-			tag.SetFieldByName(fields[fieldIndex], fieldAggregator)
-			fieldIndex++
-			fieldAggregator = ""
-		case "Address":
-			if theOneBeforeChar == ';' && previousChar == '"' && runeValue == '\t' {
-				tag.SetFieldByName(fields[fieldIndex], fieldAggregator)
-				fieldAggregator = ""
-				fieldIndex++
-				continue
-			}
-
-			fieldAggregator += string(runeValue)
-			theOneBeforeChar = previousChar
-			previousChar = runeValue
-		case "ExtensionFields":
-			if runeValue != '\t' {
-				fieldAggregator += string(runeValue)
-				continue
-			}
-
-			extensionFieldKey, extextensionFieldVal := extensionFieldFromAggregator(fieldAggregator)
-			extensionFields[extensionFieldKey] = extextensionFieldVal
-
-			fieldAggregator = ""
-		}
+
+	name, rest, found := strings.Cut(text, "\t")
+	if name == "" {
+		return TagEntry{}, &ParseError{Column: 1, Message: "empty tag name"}
 	}
+	if !found {
+		return TagEntry{}, &ParseError{Column: len(text) + 1, Message: "missing tab after tag name"}
+	}
+	tag.Name = name
 
-	if fieldAggregator != "" {
-		switch fields[fieldIndex] {
-		case "ExtensionFields":
-			extensionFieldKey, extextensionFieldVal := extensionFieldFromAggregator(fieldAggregator)
-			extensionFields[extensionFieldKey] = extextensionFieldVal
+	column := len(name) + 2
+	fileName, rest, found := strings.Cut(rest, "\t")
+	if fileName == "" {
+		return TagEntry{}, &ParseError{Column: column, Message: "empty file name"}
+	}
+	if !found {
+		return TagEntry{}, &ParseError{Column: column + len(fileName), Message: "missing tab after file name"}
+	}
+	tag.FileName = fileName
+
+	column += len(fileName) + 1
+	addressLength, err := addressLength(rest, column)
+	if err != nil {
+		return TagEntry{}, err
+	}
+	tag.Address = rest[:addressLength] + `;"`
+
+	column += addressLength
+	rest = rest[addressLength:]
+	if rest == "" {
+		return tag, nil
+	}
+
+	if !strings.HasPrefix(rest, `;"`) {
+		return TagEntry{}, &ParseError{Column: column, Message: `expected ';"' after the address`}
+	}
 
-			tag.SetFieldByName("ExtensionFields", extensionFields)
-		default:
-			tag.SetFieldByName(fields[fieldIndex], fieldAggregator)
+	column += 2
+	rest = rest[2:]
+	if rest == "" {
+		return tag, nil
+	}
+
+	if rest[0] != '\t' {
+		return TagEntry{}, &ParseError{Column: column, Message: `expected tab after ';"'`}
+	}
+
+	for _, field := range strings.Split(rest[1:], "\t") {
+		column++
+
+		if err = tag.setField(field, column); err != nil {
+			return TagEntry{}, err
 		}
+
+		column += len(field)
 	}
 
 	return tag, nil
 }
 
-func extensionFieldFromAggregator(fieldAggregator string) (key, value string) {
-	splits := strings.Split(fieldAggregator, ":")
-	splitsCount := len(splits)
-	if splitsCount == 2 {
-		return splits[0], splits[1]
-	} else {
-		return strings.Join(splits[:2], ":"), splits[splitsCount-1]
+// addressLength returns the length of the address at the start of text: a
+// line number, a pattern, or a line number followed by a pattern, like
+// '12;/^func main$/', the way u-ctags writes addresses with '--excmd=combine'.
+// Column is the position of text in the line, for the errors.
+func addressLength(text string, column int) (int, error) {
+	if text == "" {
+		return 0, &ParseError{Column: column, Message: "empty address"}
+	}
+
+	if text[0] == '/' || text[0] == '?' {
+		return patternLength(text, column)
+	}
+
+	length := 0
+	for length < len(text) && text[length] >= '0' && text[length] <= '9' {
+		length++
+	}
+
+	if length == 0 {
+		return 0, &ParseError{Column: column, Message: "address should be a line number or a /pattern/ or ?pattern?"}
+	}
+
+	if strings.HasPrefix(text[length:], ";/") || strings.HasPrefix(text[length:], ";?") {
+		patternLength, err := patternLength(text[length+1:], column+length+1)
+		return length + 1 + patternLength, err
+	}
+
+	return length, nil
+}
+
+// patternLength returns the length of the /pattern/ or ?pattern? at the start
+// of text.
+func patternLength(text string, column int) (int, error) {
+	delimiter := text[0]
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case delimiter:
+			return i + 1, nil
+		}
 	}
+
+	return 0, &ParseError{Column: column + len(text), Message: fmt.Sprintf("unterminated %c pattern in the address", delimiter)}
+}
+
+func (t *TagEntry) setField(field string, column int) error {
+	if field == "" {
+		return &ParseError{Column: column, Message: "empty extension field"}
+	}
+
+	key, value, found := strings.Cut(field, ":")
+	if key == "" {
+		return &ParseError{Column: column, Message: "extension field without a name"}
+	}
+
+	if !found || key == "kind" {
+		if t.Kind != "" {
+			return &ParseError{Column: column, Message: "more than one kind"}
+		}
+
+		t.Kind = key
+		if found {
+			t.Kind = value
+		}
+
+		return nil
+	}
+
+	// 'typeref:typename:string' is stored with the 'typeref:typename' key
+	if key == "typeref" {
+		if prefix, typeName, found := strings.Cut(value, ":"); found {
+			key, value = key+":"+prefix, typeName
+		}
+	}
+
+	if t.ExtensionFields == nil {
+		t.ExtensionFields = map[string]string{}
+	}
+
+	t.ExtensionFields[key] = unescapeFieldValue(value)
+
+	return nil
+}
+
+var fieldValueUnescaper = strings.NewReplacer(`\\`, `\`, `\t`, "\t", `\r`, "\r", `\n`, "\n")
+
+func unescapeFieldValue(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	return fieldValueUnescaper.Replace(value)
 }
@@ -1,6 +1,9 @@
 package common
 
 import (
+	"errors"
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,15 +13,164 @@ func TestTagFromString(t *testing.T) {
 	text := `FileName	golang/extract_tags.go	/^	FileName    string$/;"	m	struct:golang.Processor	typeref:typename:string`
 	tag, _ := TagFromString(text)
 	expectedTag := TagEntry{
-		"FileName",
-		"golang/extract_tags.go",
-		`/^	FileName    string$/;"`,
-		"m",
-		map[string]string{
-			"struct": "golang.Processor",
+		Name:     "FileName",
+		FileName: "golang/extract_tags.go",
+		Address:  `/^	FileName    string$/;"`,
+		Kind:     "m",
+		ExtensionFields: map[string]string{
+			"struct":           "golang.Processor",
 			"typeref:typename": "string",
 		},
 	}
 
 	assert.Equal(t, expectedTag, tag)
 }
+
+func TestTagFromStringAddresses(t *testing.T) {
+	tests := []struct {
+		text        string
+		expectedTag TagEntry
+	}{
+		{
+			text:        "main\tmain.go\t12;\"\tf",
+			expectedTag: TagEntry{Name: "main", FileName: "main.go", Address: `12;"`, Kind: "f"},
+		},
+		{
+			text:        "main\tmain.go\t12",
+			expectedTag: TagEntry{Name: "main", FileName: "main.go", Address: `12;"`},
+		},
+		{
+			text:        "s\ts.go\t/^var s = \";\"\tx\"$/;\"\tv",
+			expectedTag: TagEntry{Name: "s", FileName: "s.go", Address: "/^var s = \";\"\tx\"$/;\"", Kind: "v"},
+		},
+		{
+			text:        `p	p.go	/^var p = "\/\\"$/;"	v`,
+			expectedTag: TagEntry{Name: "p", FileName: "p.go", Address: `/^var p = "\/\\"$/;"`, Kind: "v"},
+		},
+		{
+			text:        `b	b.go	?^func b()$?;"	kind:f`,
+			expectedTag: TagEntry{Name: "b", FileName: "b.go", Address: `?^func b()$?;"`, Kind: "f"},
+		},
+		{
+			text:        `main	main.go	12;/^func main$/;"	f	line:12`,
+			expectedTag: TagEntry{Name: "main", FileName: "main.go", Address: `12;/^func main$/;"`, Kind: "f", ExtensionFields: map[string]string{"line": "12"}},
+		},
+		{
+			text:        `c	c.go	3;?^var c = "\?;"$?;"	v`,
+			expectedTag: TagEntry{Name: "c", FileName: "c.go", Address: `3;?^var c = "\?;"$?;"`, Kind: "v"},
+		},
+	}
+
+	for _, test := range tests {
+		tag, err := TagFromString(test.text)
+		assert.NoError(t, err, test.text)
+		assert.Equal(t, test.expectedTag, tag, test.text)
+	}
+}
+
+func TestTagFromStringFields(t *testing.T) {
+	text := `x	x.go	/^var x$/;"	v	file:	doc:a\tb\\c\nd	typeref:typename:map[string]int	signature:(a, b string)`
+	tag, err := TagFromString(text)
+	assert.NoError(t, err)
+
+	expectedFields := map[string]string{
+		"file":             "",
+		"doc":              "a\tb\\c\nd",
+		"typeref:typename": "map[string]int",
+		"signature":        "(a, b string)",
+	}
+	assert.Equal(t, "v", tag.Kind)
+	assert.Equal(t, expectedFields, tag.ExtensionFields)
+}
+
+func TestTagFromStringErrors(t *testing.T) {
+	tests := []struct {
+		text           string
+		expectedColumn int
+	}{
+		{text: "", expectedColumn: 1},
+		{text: "\tmain.go\t1", expectedColumn: 1},
+		{text: "main", expectedColumn: 5},
+		{text: "main\tmain.go", expectedColumn: 13},
+		{text: "main\t\t1", expectedColumn: 6},
+		{text: "main\tmain.go\t", expectedColumn: 14},
+		{text: "main\tmain.go\tmain", expectedColumn: 14},
+		{text: "main\tmain.go\t/^func main", expectedColumn: 25},
+		{text: "main\tmain.go\t12x", expectedColumn: 16},
+		{text: "main\tmain.go\t12;/^func", expectedColumn: 23},
+		{text: "main\tmain.go\t12;\"f", expectedColumn: 18},
+		{text: "main\tmain.go\t12;\"\tf\t\tline:12", expectedColumn: 21},
+		{text: "main\tmain.go\t12;\"\tf\t:12", expectedColumn: 21},
+		{text: "main\tmain.go\t12;\"\tf\tv", expectedColumn: 21},
+	}
+
+	for _, test := range tests {
+		_, err := TagFromString(test.text)
+
+		var parseError *ParseError
+		if assert.ErrorAs(t, err, &parseError, test.text) {
+			assert.Equal(t, test.expectedColumn, parseError.Column, test.text)
+		}
+	}
+}
+
+func FuzzTagFromString(f *testing.F) {
+	f.Add(`FileName	golang/extract_tags.go	/^	FileName    string$/;"	m	struct:golang.Processor	typeref:typename:string`)
+	f.Add("main\tmain.go\t12;\"\tf\tfile:")
+	f.Add(`p	p.go	?^var p = "\?\\"$?;"	v	doc:a\tb`)
+	f.Add("!_TAG_FILE_SORTED\t1\t/0=unsorted, 1=sorted/")
+
+	f.Fuzz(func(t *testing.T, text string) {
+		tag, err := TagFromString(text)
+		if errors.Is(err, ErrStringIsAComment) {
+			return
+		}
+
+		var parseError *ParseError
+		if errors.As(err, &parseError) {
+			if parseError.Column < 1 || parseError.Column > len(text)+1 {
+				t.Fatalf("column %d out of range for %q", parseError.Column, text)
+			}
+			return
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error %v for %q", err, text)
+		}
+
+		if tag.Name == "" || tag.FileName == "" || !strings.HasSuffix(tag.Address, `;"`) {
+			t.Fatalf("incomplete tag %#v for %q", tag, text)
+		}
+
+		writtenTag, err := TagFromString(string(tag.Bytes()))
+		if err != nil {
+			t.Fatalf("error %v parsing the written tag of %q", err, text)
+		}
+
+		if !reflect.DeepEqual(tag, writtenTag) {
+			t.Fatalf("written tag %#v differs from %#v for %q", writtenTag, tag, text)
+		}
+	})
+}
+
+func TestTagBytesRoundTrip(t *testing.T) {
+	tag := TagEntry{
+		Name:     "Handler",
+		FileName: "server.go",
+		Address:  `/^func Handler(s string) (struct {$/;"`,
+		Kind:     "f",
+		ExtensionFields: map[string]string{
+			"typeref:typename": "struct {\n\tText string `json:\"text\"`\n}",
+			"doc":              `Handler handles C:\paths.`,
+			"file":             "",
+		},
+	}
+
+	line := string(tag.Bytes())
+	assert.NotContains(t, line, "\n")
+	assert.Equal(t, 6, strings.Count(line, "\t"))
+
+	parsedTag, err := TagFromString(line)
+	assert.NoError(t, err)
+	assert.Equal(t, tag, parsedTag)
+}
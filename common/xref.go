@@ -0,0 +1,189 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultXrefFormat is the format of the lines of the cross reference output,
+// the same as the one of 'ctags -x': the name, kind, line number, file name and
+// source line of the tags.
+const DefaultXrefFormat = "%-16N %-10K %4n %-16F %C"
+
+// GtagsXrefFormat is the format of the lines of the GNU GLOBAL plug-in parser
+// protocol.
+const GtagsXrefFormat = "%R %-16N %4n %-16F %C"
+
+var gtagsFormat = mustParseXrefFormat(GtagsXrefFormat)
+
+// mustParseXrefFormat parses a format without extension fields, panicking
+// when it is invalid, for the formats defined in the code.
+func mustParseXrefFormat(format string) XrefFormat {
+	xrefFormat, err := ParseXrefFormat(format, nil)
+	if err != nil {
+		panic("invalid xref format " + strconv.Quote(format) + ": " + err.Error())
+	}
+
+	return xrefFormat
+}
+
+// xrefLetters are the letters of the values of the tags in xref formats
+// besides the ones of the extension fields.
+var xrefLetters = map[string]func(t TagEntry, kinds []Kind) string{
+	"N": func(t TagEntry, _ []Kind) string { return t.Name },
+	"F": func(t TagEntry, _ []Kind) string { return t.FileName },
+	"n": func(t TagEntry, _ []Kind) string { return t.ExtensionFields["line"] },
+	"K": func(t TagEntry, kinds []Kind) string { return KindName(kinds, t.Kind) },
+	"k": func(t TagEntry, _ []Kind) string { return t.Kind },
+	"C": func(t TagEntry, _ []Kind) string { return strings.Join(strings.Fields(t.sourceLine()), " ") },
+	"P": func(t TagEntry, _ []Kind) string { return strings.TrimSuffix(t.Address, ";\"") },
+	"R": func(t TagEntry, _ []Kind) string {
+		if t.ExtensionFields["roles"] == "ref" {
+			return "R"
+		}
+		return "D"
+	},
+}
+
+// sourceLine returns the source line of the tag, or the line its pattern
+// matches for tags without one, like the ones read from tags files.
+func (t TagEntry) sourceLine() string {
+	if t.SourceLine != "" {
+		return t.SourceLine
+	}
+
+	return PatternText(t.Address)
+}
+
+// XrefFormat is a u-ctags '--_xformat' style template for the lines of the
+// cross reference output. '%N' stands for the name, '%F' the file name, '%n'
+// the line number, '%K' and '%k' the long name and letter of the kind, '%C'
+// the source line with the whitespace squeezed, '%P' the pattern and '%R' 'D'
+// for definitions or 'R' for references. The other letters and '%{name}' stand
+// for extension fields, and a width like in '%-16N' pads the value, on the
+// right for negative widths. Missing values are written as '-'.
+type XrefFormat struct {
+	parts []xrefPart
+}
+
+type xrefPart struct {
+	literal string
+	width   int
+	value   func(t TagEntry, kinds []Kind) string
+}
+
+// ParseXrefFormat parses the format, with the fields giving the letters and
+// names of the extension fields.
+func ParseXrefFormat(format string, fields []Field) (XrefFormat, error) {
+	var parts []xrefPart
+
+	for len(format) > 0 {
+		i := strings.IndexByte(format, '%')
+		if i < 0 {
+			parts = append(parts, xrefPart{literal: format})
+			break
+		}
+
+		if i > 0 {
+			parts = append(parts, xrefPart{literal: format[:i]})
+		}
+		format = format[i+1:]
+
+		if strings.HasPrefix(format, "%") {
+			parts = append(parts, xrefPart{literal: "%"})
+			format = format[1:]
+			continue
+		}
+
+		widthEnd := 0
+		for widthEnd < len(format) && (format[widthEnd] == '-' && widthEnd == 0 || format[widthEnd] >= '0' && format[widthEnd] <= '9') {
+			widthEnd++
+		}
+
+		var width int
+		if widthEnd > 0 {
+			var err error
+			if width, err = strconv.Atoi(format[:widthEnd]); err != nil {
+				return XrefFormat{}, fmt.Errorf("invalid width %q", format[:widthEnd])
+			}
+		}
+		format = format[widthEnd:]
+
+		if format == "" {
+			return XrefFormat{}, fmt.Errorf("missing field letter after '%%'")
+		}
+
+		var name string
+		if format[0] == '{' {
+			end := strings.IndexByte(format, '}')
+			if end < 0 {
+				return XrefFormat{}, fmt.Errorf("missing '}' in %q", format)
+			}
+
+			name, format = format[1:end], format[end+1:]
+		} else {
+			name, format = format[:1], format[1:]
+		}
+
+		value, err := xrefValue(name, len(name) == 1, fields)
+		if err != nil {
+			return XrefFormat{}, err
+		}
+
+		parts = append(parts, xrefPart{width: width, value: value})
+	}
+
+	return XrefFormat{parts: parts}, nil
+}
+
+// xrefValue returns the function getting the value for a letter or a field
+// name. Names of fields which are not known are taken as the names of
+// extension fields, e.g. the ones of tags queries.
+func xrefValue(name string, isLetter bool, fields []Field) (func(t TagEntry, kinds []Kind) string, error) {
+	if value, ok := xrefLetters[name]; ok && isLetter {
+		return value, nil
+	}
+
+	keys, found := []string{name}, !isLetter
+	for _, field := range fields {
+		if isLetter && field.Letter == name || !isLetter && field.Name == name {
+			keys, found = field.Keys, true
+			break
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown field letter %q", name)
+	}
+
+	return func(t TagEntry, _ []Kind) string {
+		for _, key := range keys {
+			if value, ok := t.ExtensionFields[key]; ok {
+				return value
+			}
+		}
+		return ""
+	}, nil
+}
+
+// Bytes returns the line for the tag, with the long names of the kinds from
+// the kinds list.
+func (f XrefFormat) Bytes(t TagEntry, kinds []Kind) []byte {
+	var line strings.Builder
+	for _, part := range f.parts {
+		if part.value == nil {
+			line.WriteString(part.literal)
+			continue
+		}
+
+		value := part.value(t, kinds)
+		if value == "" {
+			value = "-"
+		}
+
+		fmt.Fprintf(&line, "%*s", part.width, value)
+	}
+
+	return []byte(line.String())
+}
@@ -0,0 +1,54 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXrefFormat(t *testing.T) {
+	fields := []Field{
+		{Letter: "s", Name: "scope", Keys: []string{"package", "struct"}},
+		{Name: "doc", Keys: []string{"doc"}},
+	}
+	kinds := []Kind{{Letter: "f", Name: "func"}}
+	tag := TagEntry{
+		Name:            "main",
+		FileName:        "main.go",
+		Address:         `/^func   main() {$/;"`,
+		Kind:            "f",
+		ExtensionFields: map[string]string{"line": "7", "package": "main", "route": "/"},
+	}
+
+	tests := []struct {
+		format       string
+		expectedLine string
+	}{
+		{format: DefaultXrefFormat, expectedLine: "main             func          7 main.go          func main() {"},
+		{format: "%N:%k:%s:%{doc}:%{route}:%R %%", expectedLine: "main:f:main:-:/:D %"},
+		{format: "%-6N|%3n|%P", expectedLine: `main  |  7|/^func   main() {$/`},
+	}
+
+	for _, test := range tests {
+		format, err := ParseXrefFormat(test.format, fields)
+		assert.NoError(t, err, test.format)
+		assert.Equal(t, test.expectedLine, string(format.Bytes(tag, kinds)), test.format)
+	}
+
+	for _, format := range []string{"%", "%-4", "%Q", "%{doc"} {
+		_, err := ParseXrefFormat(format, fields)
+		assert.Error(t, err, format)
+	}
+
+	// the source line is not cut off like the pattern
+	tag.Address = PatternAddress([]byte("func main(a, b int) {"), 10)
+	tag.SourceLine = "func main(a, b int) {"
+	format, err := ParseXrefFormat("%C|%P", fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "func main(a, b int) {|/^func main(/", string(format.Bytes(tag, kinds)))
+}
+
+func TestMustParseXrefFormat(t *testing.T) {
+	assert.Equal(t, "R run 3", string(mustParseXrefFormat("%R %N %n").Bytes(TagEntry{Name: "run", ExtensionFields: map[string]string{"line": "3", "roles": "ref"}}, nil)))
+	assert.Panics(t, func() { mustParseXrefFormat("%Q") })
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// projectConfigFileName is the config file read from the directory tree-tags
+// runs in, after the one in the user config directory.
+const projectConfigFileName = ".tree-tags"
+
+// optionArgs returns the options of the config files followed by the ones of
+// the command line, so that the command line ones take precedence. The
+// '--regex-<language>' options are taken out, as their names are not known
+// in advance.
+func optionArgs() ([]string, error) {
+	args, err := configArgs(flag.CommandLine, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return extractRegexOptions(append(args, os.Args[1:]...))
+}
+
+// subcommandArgs returns the options of the config files for a subcommand
+// followed by the arguments of the command line.
+func subcommandArgs(flagSet *flag.FlagSet, args []string) []string {
+	configArgs, err := configArgs(flagSet, false)
+	if err != nil {
+		log.Fatal("error while reading options:", err.Error())
+	}
+
+	return append(configArgs, args...)
+}
+
+// configArgs returns the arguments of the options of the config files which
+// the flag set defines, like '--doc-full' for the 'scip' subcommand, and the
+// '--regex-<language>' options when asked for. The config files are shared
+// by the subcommands, so the options of the other subcommands are left out.
+func configArgs(flagSet *flag.FlagSet, regexOptions bool) ([]string, error) {
+	configOptions, err := readConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	for _, option := range configOptions {
+		name, _, _ := strings.Cut(strings.TrimLeft(option[0], "-"), "=")
+		if flagSet.Lookup(name) != nil || regexOptions && strings.HasPrefix(name, "regex-") {
+			args = append(args, option...)
+		}
+	}
+
+	return args, nil
+}
+
+// readConfigFiles returns the options of the config file in the user config
+// directory followed by the ones of the config file of the project, as the
+// arguments of each option.
+func readConfigFiles() ([][]string, error) {
+	var configOptions [][]string
+
+	configFileNames := []string{projectConfigFileName}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		configFileNames = append([]string{filepath.Join(configDir, "tree-tags", "config")}, configFileNames...)
+	}
+
+	for _, configFileName := range configFileNames {
+		fileOptions, err := readConfigFile(configFileName)
+		if err != nil {
+			return nil, err
+		}
+
+		configOptions = append(configOptions, fileOptions...)
+	}
+
+	return configOptions, nil
+}
+
+// readConfigFile returns the options in the config file, one per line, like
+// '--exported-only', '--fields=+n' or '--regex-go=/ROUTE: (.*)/\1/r,route/',
+// as the arguments of each option. The value of an option can also follow
+// its name after spaces, like in '--fields +n', and is taken as it is, the
+// same as after '=', without shell quoting. Empty lines and lines starting
+// with '#' are skipped. Missing config files have no options.
+func readConfigFile(fileName string) ([][]string, error) {
+	file, err := os.Open(fileName)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var configOptions [][]string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// the value after '=' can have spaces, like the ones of regex
+		// definitions
+		i := strings.IndexFunc(line, unicode.IsSpace)
+		if i < 0 || strings.Contains(line[:i], "=") {
+			configOptions = append(configOptions, []string{line})
+			continue
+		}
+
+		configOptions = append(configOptions, []string{line[:i], strings.TrimSpace(line[i:])})
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	return configOptions, nil
+}
+
+// extractRegexOptions adds the definitions of the '--regex-<language>=...'
+// options, whose value can also be the next argument, to the options and
+// returns the other arguments.
+func extractRegexOptions(args []string) ([]string, error) {
+	var otherArgs []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(otherArgs, args[i:]...), nil
+		}
+
+		option, found := strings.CutPrefix(strings.TrimPrefix(arg, "-"), "-regex-")
+		if !found {
+			otherArgs = append(otherArgs, arg)
+			continue
+		}
+
+		language, value, found := strings.Cut(option, "=")
+		if !found && i+1 < len(args) {
+			i++
+			value, found = args[i], true
+		}
+
+		if !found {
+			return nil, fmt.Errorf("option %s should be given as --regex-<language>=/regexp/replacement/[kind-spec/][flags]", arg)
+		}
+
+		definition, err := common.ParseRegexDefinition(language, value)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %w", arg, err)
+		}
+
+		options.RegexDefinitions = append(options.RegexDefinitions, definition)
+	}
+
+	return otherArgs, nil
+}
+
+// isTaggedFile reports whether tags are extracted from the file, which is the
+// case for go files and the files regex definitions apply to.
+func isTaggedFile(fileName string) bool {
+	if path.Ext(fileName) == ".go" {
+		return true
+	}
+
+	for _, definition := range options.RegexDefinitions {
+		if definition.AppliesTo(fileName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// regexFileTags returns the tags of the regex definitions for a file without
+// a grammar.
+func regexFileTags(fileName string) []common.TagEntry {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		log.Fatal("error while trying to read file:", fileName, err.Error())
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+	lineBytes := make([][]byte, len(lines))
+	for i, line := range lines {
+		lineBytes[i] = []byte(strings.TrimSuffix(line, "\r"))
+	}
+
+	return common.RegexTags(fileName, lineBytes, options)
+}
@@ -0,0 +1,75 @@
+// Package filelist handles the names of the files whose tags are updated in
+// append mode: reading them from lists like 'git diff --name-only' output,
+// normalizing them, and picking the tags of the old tags file to keep.
+package filelist
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// Read returns the file names of a list with one name per line, skipping
+// blank lines.
+func Read(reader io.Reader) ([]string, error) {
+	var fileNames []string
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		if fileName := strings.TrimSpace(scanner.Text()); fileName != "" {
+			fileNames = append(fileNames, fileName)
+		}
+	}
+
+	return fileNames, scanner.Err()
+}
+
+// Normalize cleans the file names, so that e.g. './a.go' and 'a.go' refer to
+// the same tags, and drops duplicates.
+func Normalize(fileNames []string) []string {
+	seen := map[string]bool{}
+
+	var normalized []string
+	for _, fileName := range fileNames {
+		fileName = filepath.Clean(fileName)
+		if !seen[fileName] {
+			seen[fileName] = true
+			normalized = append(normalized, fileName)
+		}
+	}
+
+	return normalized
+}
+
+// KeepTag returns a function reporting whether a tag of the old tags file is
+// kept: tags of the updated files are dropped, as they are generated again,
+// and with prune the tags of files for which exists returns false. Each file
+// is only checked once.
+func KeepTag(updatedFileNames []string, prune bool, exists func(fileName string) bool) func(common.TagEntry) bool {
+	updated := map[string]bool{}
+	for _, fileName := range updatedFileNames {
+		updated[filepath.Clean(fileName)] = true
+	}
+
+	existing := map[string]bool{}
+
+	return func(tag common.TagEntry) bool {
+		fileName := filepath.Clean(tag.FileName)
+		if updated[fileName] {
+			return false
+		}
+
+		if !prune {
+			return true
+		}
+
+		if _, ok := existing[fileName]; !ok {
+			existing[fileName] = exists(fileName)
+		}
+
+		return existing[fileName]
+	}
+}
@@ -0,0 +1,81 @@
+package filelist
+
+import (
+	"strings"
+	"testing"
+
+	common "github.com/jha-naman/tree-tags/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedFileNames []string
+	}{
+		{input: "", expectedFileNames: nil},
+		{input: "a.go\nb/c.go\n", expectedFileNames: []string{"a.go", "b/c.go"}},
+		{input: "\n  a.go  \r\n\nb.go", expectedFileNames: []string{"a.go", "b.go"}},
+	}
+
+	for _, test := range tests {
+		fileNames, err := Read(strings.NewReader(test.input))
+		assert.NoError(t, err, test.input)
+		assert.Equal(t, test.expectedFileNames, fileNames, test.input)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		fileNames         []string
+		expectedFileNames []string
+	}{
+		{fileNames: nil, expectedFileNames: nil},
+		{fileNames: []string{"./a.go", "a.go"}, expectedFileNames: []string{"a.go"}},
+		{fileNames: []string{"b//c.go", "b/../b/c.go", "./d/"}, expectedFileNames: []string{"b/c.go", "d"}},
+		{fileNames: []string{"b.go", "a.go", "b.go"}, expectedFileNames: []string{"b.go", "a.go"}},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedFileNames, Normalize(test.fileNames), test.fileNames)
+	}
+}
+
+func TestKeepTag(t *testing.T) {
+	exists := func(fileName string) bool { return fileName != "deleted.go" }
+
+	tests := []struct {
+		name             string
+		updatedFileNames []string
+		prune            bool
+		fileName         string
+		expectedKeep     bool
+	}{
+		{name: "other file", updatedFileNames: []string{"a.go"}, fileName: "b.go", expectedKeep: true},
+		{name: "updated file", updatedFileNames: []string{"a.go"}, fileName: "a.go", expectedKeep: false},
+		{name: "updated file with another spelling", updatedFileNames: []string{"./a.go"}, fileName: "a.go", expectedKeep: false},
+		{name: "tag file name with another spelling", updatedFileNames: []string{"a.go"}, fileName: "./a.go", expectedKeep: false},
+		{name: "deleted file", updatedFileNames: []string{"a.go"}, fileName: "deleted.go", expectedKeep: true},
+		{name: "deleted file pruned", updatedFileNames: []string{"a.go"}, prune: true, fileName: "deleted.go", expectedKeep: false},
+		{name: "existing file pruned", updatedFileNames: []string{"a.go"}, prune: true, fileName: "b.go", expectedKeep: true},
+	}
+
+	for _, test := range tests {
+		keep := KeepTag(test.updatedFileNames, test.prune, exists)
+		assert.Equal(t, test.expectedKeep, keep(common.TagEntry{FileName: test.fileName}), test.name)
+	}
+}
+
+func TestKeepTagChecksFilesOnce(t *testing.T) {
+	checks := 0
+	keep := KeepTag(nil, true, func(string) bool {
+		checks++
+		return true
+	})
+
+	for range 3 {
+		keep(common.TagEntry{FileName: "a.go"})
+	}
+
+	assert.Equal(t, 1, checks)
+}
@@ -0,0 +1,108 @@
+package golang
+
+import (
+	"strconv"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// evaluateIotaExpression returns the value of a constant expression using
+// iota, like '1 << iota'. Expressions not using iota, or using anything other
+// than integer literals and arithmetic operators, are not evaluated.
+func (p *Processor) evaluateIotaExpression(node *sitter.Node, iota int64) (string, bool) {
+	value, usesIota, ok := p.evaluateIntExpression(node, iota)
+	if !ok || !usesIota {
+		return "", false
+	}
+
+	return strconv.FormatInt(value, 10), true
+}
+
+func (p *Processor) evaluateIntExpression(node *sitter.Node, iota int64) (value int64, usesIota, ok bool) {
+	switch node.Type() {
+	case "iota":
+		return iota, true, true
+	case "int_literal":
+		value, err := strconv.ParseInt(p.stringFromByteRange(p.FileBytes, node.Range()), 0, 64)
+		return value, false, err == nil
+	case "parenthesized_expression":
+		if node.NamedChildCount() != 1 {
+			return 0, false, false
+		}
+		return p.evaluateIntExpression(node.NamedChild(0), iota)
+	case "unary_expression":
+		operand := node.ChildByFieldName("operand")
+		operator := node.ChildByFieldName("operator")
+		if operand == nil || operator == nil {
+			return 0, false, false
+		}
+
+		value, usesIota, ok := p.evaluateIntExpression(operand, iota)
+		if !ok {
+			return 0, false, false
+		}
+
+		switch operator.Type() {
+		case "+":
+			return value, usesIota, true
+		case "-":
+			return -value, usesIota, true
+		case "^":
+			return ^value, usesIota, true
+		}
+	case "binary_expression":
+		left, right := node.ChildByFieldName("left"), node.ChildByFieldName("right")
+		operator := node.ChildByFieldName("operator")
+		if left == nil || right == nil || operator == nil {
+			return 0, false, false
+		}
+
+		leftValue, leftUsesIota, leftOk := p.evaluateIntExpression(left, iota)
+		rightValue, rightUsesIota, rightOk := p.evaluateIntExpression(right, iota)
+		if !leftOk || !rightOk {
+			return 0, false, false
+		}
+
+		value, ok := evaluateBinaryOperator(operator.Type(), leftValue, rightValue)
+		return value, leftUsesIota || rightUsesIota, ok
+	}
+
+	return 0, false, false
+}
+
+func evaluateBinaryOperator(operator string, left, right int64) (int64, bool) {
+	switch operator {
+	case "+":
+		return left + right, true
+	case "-":
+		return left - right, true
+	case "*":
+		return left * right, true
+	case "/", "%":
+		if right == 0 {
+			return 0, false
+		}
+		if operator == "/" {
+			return left / right, true
+		}
+		return left % right, true
+	case "<<", ">>":
+		if right < 0 || right > 63 {
+			return 0, false
+		}
+		if operator == "<<" {
+			return left << right, true
+		}
+		return left >> right, true
+	case "&":
+		return left & right, true
+	case "|":
+		return left | right, true
+	case "^":
+		return left ^ right, true
+	case "&^":
+		return left &^ right, true
+	}
+
+	return 0, false
+}
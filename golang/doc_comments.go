@@ -0,0 +1,172 @@
+package golang
+
+import (
+	"regexp"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Lines like '//go:generate' or '//nolint:errcheck' are directives and not
+// part of the documentation, same as go/doc treats them.
+var directiveRegex = regexp.MustCompile(`^//[a-z0-9]+:[a-z0-9]`)
+
+// docComment returns the text of the comment block ending on the line right
+// before the given declaration node. When the node is the only spec of a
+// declaration, e.g. 'type Foo int', the comment block before the declaration
+// is used instead.
+func (p *Processor) docComment(node *sitter.Node) string {
+	if doc := p.precedingCommentText(node); doc != "" {
+		return doc
+	}
+
+	parent := node.Parent()
+	if parent == nil {
+		return ""
+	}
+
+	switch parent.Type() {
+	case "var_spec_list", "const_declaration", "var_declaration", "type_declaration":
+	default:
+		return ""
+	}
+
+	if parent.Type() == "var_spec_list" {
+		parent = parent.Parent()
+	}
+
+	if parent == nil || countSpecs(parent) != 1 {
+		return ""
+	}
+
+	return p.precedingCommentText(parent)
+}
+
+func countSpecs(declarationNode *sitter.Node) int {
+	count := 0
+	for i := 0; i < int(declarationNode.NamedChildCount()); i++ {
+		switch child := declarationNode.NamedChild(i); child.Type() {
+		case "var_spec_list":
+			count += countSpecs(child)
+		case "type_spec", "type_alias", "var_spec", "const_spec":
+			count++
+		}
+	}
+
+	return count
+}
+
+func (p *Processor) precedingCommentText(node *sitter.Node) string {
+	var comments []*sitter.Node
+
+	expectedEndRow := int(node.StartPoint().Row) - 1
+	for sibling := node.PrevNamedSibling(); sibling != nil; sibling = sibling.PrevNamedSibling() {
+		if sibling.Type() != "comment" || int(sibling.EndPoint().Row) != expectedEndRow {
+			break
+		}
+
+		// a comment trailing some code belongs to that code and not to the node
+		if previous := sibling.PrevNamedSibling(); previous != nil && previous.EndPoint().Row == sibling.StartPoint().Row {
+			break
+		}
+
+		comments = append([]*sitter.Node{sibling}, comments...)
+		expectedEndRow = int(sibling.StartPoint().Row) - 1
+	}
+
+	var lines []string
+	for _, comment := range comments {
+		lines = append(lines, p.commentLines(comment)...)
+	}
+
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// commentLines returns the lines of a comment with the comment markers removed.
+func (p *Processor) commentLines(comment *sitter.Node) []string {
+	startPoint, endPoint := comment.StartPoint(), comment.EndPoint()
+
+	var lines []string
+	for row := startPoint.Row; row <= endPoint.Row; row++ {
+		line := p.FileBytes[row]
+		if row == endPoint.Row {
+			line = line[:endPoint.Column]
+		}
+		if row == startPoint.Row {
+			line = line[startPoint.Column:]
+		}
+		lines = append(lines, string(line))
+	}
+
+	if strings.HasPrefix(lines[0], "//") {
+		// the generated code header marks the file, it does not document the
+		// declaration after it
+		if directiveRegex.MatchString(lines[0]) || generatedCodeRegex.MatchString(strings.TrimRight(lines[0], "\r")) {
+			return nil
+		}
+
+		return []string{strings.TrimPrefix(strings.TrimPrefix(lines[0], "//"), " ")}
+	}
+
+	lines[0] = strings.TrimPrefix(lines[0], "/*")
+	lines[len(lines)-1] = strings.TrimSuffix(lines[len(lines)-1], "*/")
+
+	return lines
+}
+
+// docSynopsis returns the first sentence of the documentation, with the white
+// space collapsed so that it fits on a single tag line.
+func docSynopsis(doc string) string {
+	paragraph, _, _ := strings.Cut(doc, "\n\n")
+	paragraph = strings.Join(strings.Fields(paragraph), " ")
+
+	for i := 0; i < len(paragraph); i++ {
+		if paragraph[i] == '.' && (i+1 == len(paragraph) || paragraph[i+1] == ' ') {
+			return paragraph[:i+1]
+		}
+	}
+
+	return paragraph
+}
+
+// isDeprecated reports whether a paragraph of the documentation starts with
+// 'Deprecated: ', which is how go marks deprecated identifiers.
+func isDeprecated(doc string) bool {
+	for _, paragraph := range strings.Split(doc, "\n\n") {
+		if strings.HasPrefix(strings.TrimSpace(paragraph), "Deprecated: ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setDocFields adds the doc comment of the declaration node to the tags
+// extracted from it.
+func (p *Processor) setDocFields(tags []common.TagEntry, node *sitter.Node) {
+	p.setDoc(tags, p.docComment(node))
+}
+
+// setDoc adds the documentation text, along with its synopsis and whether it
+// marks the identifier as deprecated, to the tags.
+func (p *Processor) setDoc(tags []common.TagEntry, doc string) {
+	if doc == "" {
+		return
+	}
+
+	for i := range tags {
+		if tags[i].ExtensionFields == nil {
+			tags[i].ExtensionFields = map[string]string{}
+		}
+
+		tags[i].Doc = doc
+		if synopsis := docSynopsis(doc); synopsis != "" {
+			tags[i].ExtensionFields["doc"] = synopsis
+		}
+
+		if isDeprecated(doc) {
+			tags[i].ExtensionFields["deprecated"] = "yes"
+		}
+	}
+}
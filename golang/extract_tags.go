@@ -1,84 +1,267 @@
 package golang
 
 import (
-	"bufio"
 	"bytes"
 	"context"
-	"fmt"
+	_ "embed"
+	"go/token"
+	"io"
 	"log"
 	"os"
-	"regexp"
-	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
 	common "github.com/jha-naman/tree-tags/common"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
 
 	sitter "github.com/smacker/go-tree-sitter"
 	"github.com/smacker/go-tree-sitter/golang"
 )
 
+//go:embed queries/tags.scm
+var tagsQuerySource []byte
+
+// tagsQuery is the query finding the definitions tags are extracted for.
+var tagsQuery = sync.OnceValue(func() *tagquery.Query {
+	query, err := NewTagsQuery(tagsQuerySource)
+	if err != nil {
+		log.Fatal("error in the go tags query:", err.Error())
+	}
+
+	return query
+})
+
+// NewTagsQuery compiles a tags query for go files, following the conventions
+// described in the tagquery package.
+func NewTagsQuery(source []byte) (*tagquery.Query, error) {
+	return tagquery.NewQuery(source, golang.GetLanguage())
+}
+
 type Processor struct {
 	Tags        []common.TagEntry
 	FileBytes   [][]byte
 	FileName    string
+	Options     common.Options
 	packageName string
-	cursor      *sitter.TreeCursor
+	// start bytes of the names tagged by the go tags query, so that the
+	// queries of the 'tags-query' option do not tag them again
+	taggedNames map[uint32]bool
+	// name the file imports the 'testing' package with, for telling test
+	// functions apart by their parameters
+	testingName string
+}
+
+// NewProcessor returns a processor for the go source read from the reader.
+// The file name is only used for the tags, so unsaved editor buffers can be
+// tagged under the name of the file they are for.
+func NewProcessor(fileName string, reader io.Reader, options common.Options) (*Processor, error) {
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewProcessorFromBytes(fileName, content, options), nil
 }
 
-func GetFileTags(fileName string) []common.TagEntry {
+func NewProcessorFromBytes(fileName string, content []byte, options common.Options) *Processor {
+	fileBytes := bytes.Split(content, []byte("\n"))
+	for i, line := range fileBytes {
+		fileBytes[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+
+	return &Processor{FileName: fileName, FileBytes: fileBytes, Options: options}
+}
+
+func GetFileTags(fileName string, options common.Options) []common.TagEntry {
 	file, err := os.Open(fileName)
 	if err != nil {
-		log.Fatal("error while trying to read file:", file, err.Error())
+		log.Fatal("error while trying to read file:", fileName, err.Error())
 	}
+	defer file.Close()
 
-	var fileBytes [][]byte
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		fileBytes = append(fileBytes, slices.Clone(scanner.Bytes()))
+	p, err := NewProcessor(fileName, file, options)
+	if err != nil {
+		log.Fatal("error while trying to read file:", fileName, err.Error())
 	}
 
-	p := Processor{FileName: fileName, FileBytes: fileBytes}
 	return p.GetTags()
 }
 
 func (p *Processor) GetTags() []common.TagEntry {
+	source := bytes.Join(p.FileBytes, []byte("\n"))
 	parser := getGolangParser()
-	tree, err := parser.ParseCtx(context.TODO(), nil, bytes.Join(p.FileBytes, []byte("\n")))
+	tree, err := parser.ParseCtx(context.TODO(), nil, source)
 	if err != nil {
 		log.Fatal("error while parsing file:", p.FileName, err.Error())
 	}
 
-	p.cursor = sitter.NewTreeCursor(tree.RootNode())
-	p.extractTags()
+	p.taggedNames = map[uint32]bool{}
+	p.testingName = "testing"
+	p.extractTags(tagsQuery().Definitions(tree.RootNode(), source))
+
+	for _, querySource := range p.Options.TagsQueries {
+		query, err := cachedTagsQuery(querySource)
+		if err != nil {
+			log.Fatal("error in tags query:", err.Error())
+		}
+
+		p.extractQueryTags(query.Definitions(tree.RootNode(), source))
+	}
+
+	p.Tags = append(p.Tags, common.RegexTags(p.FileName, p.FileBytes, p.Options)...)
+
+	if p.isGeneratedFile() {
+		p.markTagsAsGenerated()
+	}
+
+	p.setAccessFields()
+
+	if p.Options.References {
+		p.Tags = append(p.Tags, p.referenceTags(tree.RootNode(), source)...)
+	}
+
+	if p.Options.SourceLines {
+		p.setSourceLines()
+	}
 
 	return p.Tags
 }
 
-func (p *Processor) extractTags() {
-	cursor := p.cursor
-	node := cursor.CurrentNode()
+// setSourceLines sets the source lines of the tags from their line numbers.
+func (p *Processor) setSourceLines() {
+	for i := range p.Tags {
+		line, err := strconv.Atoi(p.Tags[i].ExtensionFields["line"])
+		if err == nil && line > 0 && line <= len(p.FileBytes) {
+			p.Tags[i].SourceLine = string(p.FileBytes[line-1])
+		}
+	}
+}
+
+// extractTags adds the tags for the definitions found by the go tags query.
+// The definitions sharing a node, like the names of 'var a, b int', are
+// processed together, as they share the type, doc comment and struct tag.
+func (p *Processor) extractTags(definitions []tagquery.Definition) {
+	for len(definitions) > 0 {
+		count := 1
+		for count < len(definitions) && definitions[count].Kind == definitions[0].Kind &&
+			definitions[count].Node.StartByte() == definitions[0].Node.StartByte() {
+			count++
+		}
+
+		group := definitions[:count]
+		definitions = definitions[count:]
 
-	switch node.Type() {
-	case "package_clause":
-		p.processPackageDeclaration()
-	case "import_declaration":
-		p.processImportDeclaration()
-	case "function_declaration":
-		p.processFunctionDeclaration()
-	case "var_declaration":
-		p.processVarDeclaration()
-	case "const_declaration":
-		p.processConstDeclaration()
-	case "type_declaration":
-		p.processTypeDeclaration()
-	case "method_declaration":
-		p.processMethodDeclaration()
+		for _, definition := range group {
+			p.taggedNames[definition.Name.StartByte()] = true
+		}
+
+		switch definition := group[0]; definition.Kind {
+		case "package":
+			p.processPackageClause(definition)
+		case "packageName":
+			p.processImportSpec(definition)
+		case "func":
+			if definition.Node.Type() == "method_declaration" {
+				p.processMethodDeclaration(definition)
+			} else {
+				p.processFunctionDeclaration(definition)
+			}
+		case "var":
+			p.processVarSpec(group)
+		case "const":
+			p.processConstSpec(group)
+		case "type", "struct", "interface", "talias":
+			p.processTypeSpec(definition)
+		case "member":
+			p.processFieldDeclaration(group)
+		case "methodSpec":
+			p.processMethodSpec(definition)
+		}
+	}
+}
+
+// definitionTags returns the tags for the names of the definitions, with the
+// line of the name and the last line of the defining node.
+func (p *Processor) definitionTags(definitions []tagquery.Definition, kind string) []common.TagEntry {
+	tags := make([]common.TagEntry, 0, len(definitions))
+	for _, definition := range definitions {
+		nameNode := definition.Name
+		tags = append(tags, common.TagEntry{
+			Name:            p.stringFromByteRange(p.FileBytes, nameNode.Range()),
+			FileName:        p.FileName,
+			Address:         p.addressStringFromBytes(p.FileBytes[nameNode.StartPoint().Row]),
+			Kind:            kind,
+			ExtensionFields: map[string]string{"line": lineNumberOf(nameNode), "end": endLineNumberOf(definition.Node)},
+			Column:          p.columnOf(nameNode),
+		})
+	}
+
+	return tags
+}
+
+// columnOf returns the 1 based column of the name node for the Column of the
+// tags, or 0 without the Columns option.
+func (p *Processor) columnOf(nameNode *sitter.Node) int {
+	if !p.Options.Columns {
+		return 0
+	}
+
+	return int(nameNode.StartPoint().Column) + 1
+}
+
+// childText returns the source of the child of the node with the given field
+// name, or an empty string when there is no such child.
+func (p *Processor) childText(node *sitter.Node, fieldName string) string {
+	child := node.ChildByFieldName(fieldName)
+	if child == nil {
+		return ""
+	}
+
+	return p.stringFromByteRange(p.FileBytes, child.Range())
+}
+
+// setAccessFields marks the tags as public or private following the go rule
+// for exported identifiers. Struct fields, interface methods and methods are
+// only exported when the type they belong to is exported too, and tags of
+// struct tag names take the access of the struct field they point to. The
+// tags of packages and import names are not identifiers of the package, so
+// they get no access field and the 'exported-only' option keeps them.
+func (p *Processor) setAccessFields() {
+	for i := range p.Tags {
+		if p.Tags[i].ExtensionFields == nil {
+			p.Tags[i].ExtensionFields = map[string]string{}
+		}
+
+		if p.Tags[i].Kind == "p" || p.Tags[i].Kind == "P" {
+			continue
+		}
+
+		name := p.Tags[i].Name
+		if aliasOf, ok := p.Tags[i].ExtensionFields["aliasof"]; ok {
+			name = aliasOf
+		}
+
+		if token.IsExported(name) && token.IsExported(memberOfTypeName(p.Tags[i], name)) {
+			p.Tags[i].ExtensionFields["access"] = "public"
+		} else {
+			p.Tags[i].ExtensionFields["access"] = "private"
+		}
 	}
+}
 
-	if cursor.GoToNextSibling() {
-		p.extractTags()
-	} else if cursor.GoToFirstChild() {
-		p.extractTags()
+// memberOfTypeName returns the name of the struct, interface or receiver type
+// of the tag, without the package name, or the given name of the tag when it
+// does not belong to a type.
+func memberOfTypeName(tag common.TagEntry, name string) string {
+	for _, key := range []string{"struct", "interface", "unkown"} {
+		if scope, ok := tag.ExtensionFields[key]; ok {
+			_, typeName, _ := strings.Cut(common.ReceiverTypeName(scope), ".")
+			return typeName
+		}
 	}
+
+	return name
 }
 
 func getGolangParser() *sitter.Parser {
@@ -88,11 +271,20 @@ func getGolangParser() *sitter.Parser {
 	return parser
 }
 
-var charsEscapeRegex = regexp.MustCompile("([$/])")
-var replaceRegex = []byte("\\${1}")
-
 func (p *Processor) addressStringFromBytes(rawBytes []byte) string {
-	return fmt.Sprintf("/^%s$/%s", string(charsEscapeRegex.ReplaceAll(rawBytes, replaceRegex)), ";\"")
+	return common.PatternAddress(rawBytes, p.Options.PatternLengthLimit)
+}
+
+// lineNumberOf returns the 1 based number of the line the node starts on, for
+// the 'line' field of the tags.
+func lineNumberOf(node *sitter.Node) string {
+	return strconv.Itoa(int(node.StartPoint().Row) + 1)
+}
+
+// endLineNumberOf returns the 1 based number of the line the node ends on, for
+// the 'end' field of the tags, given the node of the whole declaration.
+func endLineNumberOf(node *sitter.Node) string {
+	return strconv.Itoa(int(node.EndPoint().Row) + 1)
 }
 
 func (p *Processor) stringFromByteRange(fileBytes [][]byte, nodeRange sitter.Range) string {
@@ -0,0 +1,32 @@
+package golang
+
+import (
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// Fields are the extension fields of the tags extracted from go files, with
+// the letters and names of the u-ctags fields where there is one.
+var Fields = []common.Field{
+	{Letter: "n", Name: "line", Description: "line number of the definition", Keys: []string{"line"}},
+	{Letter: "e", Name: "end", Description: "line number of the end of the definition", Keys: []string{"end"}},
+	{Letter: "s", Name: "scope", Description: "package, struct, interface or receiver type the definition belongs to, or function a reference is in", Keys: []string{"package", "struct", "interface", "unkown", "func"}},
+	{Letter: "t", Name: "typeref", Description: "type of the definition", Keys: []string{"typeref:typename"}},
+	{Letter: "a", Name: "access", Description: "whether the identifier is exported, public or private", Keys: []string{"access"}},
+	{Letter: "r", Name: "roles", Description: "roles of reference tags, 'ref' for the uses of identifiers", Keys: []string{"roles"}},
+	{Letter: "S", Name: "signature", Description: "parameters of functions and methods", Keys: []string{"signature"}, DisabledByDefault: true},
+	{Name: "doc", Description: "first sentence of the doc comment", Keys: []string{"doc"}},
+	{Name: "deprecated", Description: "marks deprecated identifiers", Keys: []string{"deprecated"}},
+	{Name: "generated", Description: "marks definitions in generated files", Keys: []string{"generated"}},
+	{Name: "jsontag", Description: "name of a struct field in its json struct tag", Keys: []string{"jsontag"}},
+	{Name: "yamltag", Description: "name of a struct field in its yaml struct tag", Keys: []string{"yamltag"}},
+	{Name: "dbtag", Description: "name of a struct field in its db struct tag", Keys: []string{"dbtag"}},
+	{Name: "aliasof", Description: "struct field a struct tag name tag points to", Keys: []string{"aliasof"}},
+	{Name: "testof", Description: "function, type or method a test function is for", Keys: []string{"testof"}},
+	{Name: "enum", Description: "type of the constants of a typed const block", Keys: []string{"enum"}},
+	{Name: "value", Description: "value of constants using iota", Keys: []string{"value"}},
+	{Name: "embeds", Description: "types embedded in an interface", Keys: []string{"embeds"}},
+	{Name: "implements", Description: "interfaces a type implements", Keys: []string{"implements"}},
+	{Name: "qualifier", Description: "operand of the selector of a reference, e.g. 'fmt' for 'fmt.Println'", Keys: []string{"qualifier"}},
+	{Name: "import", Description: "import path of the package a reference is qualified with or names", Keys: []string{"import"}},
+	{Name: "local", Description: "marks references to names declared in the function they are in", Keys: []string{"local"}},
+}
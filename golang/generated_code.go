@@ -0,0 +1,54 @@
+package golang
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// Generated files are recognised the same way the go tool does it, see
+// https://pkg.go.dev/cmd/go#hdr-Generate_Go_files_by_processing_source
+var generatedCodeRegex = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// isGeneratedFile reports whether the file carries the standard generated code
+// header before the first non-comment, non-blank text in the file.
+func (p *Processor) isGeneratedFile() bool {
+	inBlockComment := false
+
+	for _, line := range p.FileBytes {
+		line = bytes.TrimRight(line, "\r")
+
+		if inBlockComment {
+			if _, after, found := bytes.Cut(line, []byte("*/")); found {
+				inBlockComment = false
+				if len(bytes.TrimSpace(after)) != 0 {
+					return false
+				}
+			}
+			continue
+		}
+
+		if generatedCodeRegex.Match(line) {
+			return true
+		}
+
+		trimmed := bytes.TrimSpace(line)
+		switch {
+		case len(trimmed) == 0, bytes.HasPrefix(trimmed, []byte("//")):
+		case bytes.HasPrefix(trimmed, []byte("/*")):
+			inBlockComment = !bytes.Contains(trimmed[2:], []byte("*/"))
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+func (p *Processor) markTagsAsGenerated() {
+	for i := range p.Tags {
+		if p.Tags[i].ExtensionFields == nil {
+			p.Tags[i].ExtensionFields = map[string]string{}
+		}
+		p.Tags[i].ExtensionFields["generated"] = "yes"
+	}
+}
@@ -0,0 +1,171 @@
+package golang
+
+import (
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// builtinInterfaceMethods are the methods of the predeclared interfaces which
+// can be embedded in other interfaces.
+var builtinInterfaceMethods = map[string][]string{
+	"any":   nil,
+	"error": {"Error"},
+}
+
+// implementsInterface is an interface the types of the tags can implement.
+type implementsInterface struct {
+	// name is the interface named by the package clause, like 'main.Reader'
+	name string
+	// directory and package name of the files of the interface, which keep
+	// apart the packages of the same name, like the 'main' packages of
+	// commands or a package and its external test package
+	directory, packageName string
+	methods                []string
+	embeds                 []string
+}
+
+// SetImplementsFields sets the 'implements' field of the tags of structs and
+// other named types to the interfaces of the tags they satisfy, like
+// 'implements:main.Reader,main.Closer'. Methods are matched by name only, as
+// the tags have no type information, the methods of pointer receivers count
+// for the type too, and interfaces without methods are left out. Types and
+// interfaces are told apart by the directory of their files, and only the
+// interfaces of their own package or the exported ones of packages they can
+// import count. The methods of embedded interfaces are added to the ones of
+// the interface when the embedded interface is one of the same package or
+// 'error', other interfaces with embedded types are left out.
+func SetImplementsFields(tags []common.TagEntry) {
+	interfaces := map[string]*implementsInterface{}
+	typeMethods := map[string]map[string]bool{}
+
+	interfaceOf := func(tag common.TagEntry, name string) *implementsInterface {
+		directory := filepath.Dir(tag.FileName)
+		key := directory + "\x00" + name
+		if interfaces[key] == nil {
+			packageName, _, _ := strings.Cut(name, ".")
+			interfaces[key] = &implementsInterface{name: name, directory: directory, packageName: packageName}
+		}
+
+		return interfaces[key]
+	}
+
+	for _, tag := range tags {
+		if tag.ExtensionFields["roles"] != "" {
+			continue
+		}
+
+		switch tag.Kind {
+		case "i":
+			iface := interfaceOf(tag, tag.ExtensionFields["package"]+"."+tag.Name)
+			if embeds := tag.ExtensionFields["embeds"]; embeds != "" {
+				iface.embeds = append(iface.embeds, strings.Split(embeds, ",")...)
+			}
+		case "n":
+			iface := interfaceOf(tag, tag.ExtensionFields["interface"])
+			iface.methods = append(iface.methods, tag.Name)
+		case "f":
+			receiver, ok := tag.ExtensionFields["unkown"]
+			if !ok {
+				continue
+			}
+
+			typeKey := filepath.Dir(tag.FileName) + "\x00" + common.ReceiverTypeName(receiver)
+			if typeMethods[typeKey] == nil {
+				typeMethods[typeKey] = map[string]bool{}
+			}
+			typeMethods[typeKey][tag.Name] = true
+		}
+	}
+
+	interfaceMethods := map[*implementsInterface][]string{}
+	for _, iface := range interfaces {
+		if methods, ok := methodSet(iface, interfaces, map[*implementsInterface]bool{}); ok && len(methods) > 0 {
+			interfaceMethods[iface] = methods
+		}
+	}
+
+	for i, tag := range tags {
+		// named types other than structs, like 'type HandlerFunc func()', have
+		// the talias kind
+		if !slices.Contains([]string{"s", "t", "a"}, tag.Kind) || tag.ExtensionFields["roles"] != "" {
+			continue
+		}
+
+		// drop the field of tags read from the tags file, their types may
+		// have lost methods since
+		delete(tag.ExtensionFields, "implements")
+
+		directory, packageName := filepath.Dir(tag.FileName), tag.ExtensionFields["package"]
+		methods := typeMethods[directory+"\x00"+packageName+"."+tag.Name]
+		if len(methods) == 0 {
+			continue
+		}
+
+		var implemented []string
+		for iface, names := range interfaceMethods {
+			if !isVisibleInterface(iface, directory, packageName) || slices.Contains(implemented, iface.name) {
+				continue
+			}
+
+			if !slices.ContainsFunc(names, func(name string) bool { return !methods[name] }) {
+				implemented = append(implemented, iface.name)
+			}
+		}
+
+		if len(implemented) > 0 {
+			slices.Sort(implemented)
+			tags[i].ExtensionFields["implements"] = strings.Join(implemented, ",")
+		}
+	}
+}
+
+// methodSet returns the methods of the interface with the ones of the
+// interfaces it embeds, and false when an embedded type is not an interface
+// of the same package or a predeclared one, like the interfaces of other
+// packages and the unions of type constraints.
+func methodSet(iface *implementsInterface, interfaces map[string]*implementsInterface, visiting map[*implementsInterface]bool) ([]string, bool) {
+	if visiting[iface] {
+		return nil, false
+	}
+	visiting[iface] = true
+	defer delete(visiting, iface)
+
+	methods := slices.Clone(iface.methods)
+	for _, embedded := range iface.embeds {
+		if builtin, ok := builtinInterfaceMethods[embedded]; ok {
+			methods = append(methods, builtin...)
+			continue
+		}
+
+		embeddedInterface, ok := interfaces[iface.directory+"\x00"+iface.packageName+"."+embedded]
+		if !ok || strings.Contains(embedded, ".") {
+			return nil, false
+		}
+
+		embeddedMethods, ok := methodSet(embeddedInterface, interfaces, visiting)
+		if !ok {
+			return nil, false
+		}
+		methods = append(methods, embeddedMethods...)
+	}
+
+	return methods, true
+}
+
+// isVisibleInterface reports whether the types of the package in the
+// directory can implement the interface: the interfaces of the package
+// itself, and the exported interfaces of packages which can be imported,
+// which 'main' and external test packages cannot.
+func isVisibleInterface(iface *implementsInterface, directory, packageName string) bool {
+	if iface.directory == directory && iface.packageName == packageName {
+		return true
+	}
+
+	_, typeName, _ := strings.Cut(iface.name, ".")
+
+	return token.IsExported(typeName) && iface.packageName != "main" && !strings.HasSuffix(iface.packageName, "_test")
+}
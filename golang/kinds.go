@@ -0,0 +1,33 @@
+package golang
+
+import (
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// Kinds are the kinds of tags extracted from go files, named the same as the
+// kinds of the u-ctags go parser where there is one.
+var Kinds = []common.Kind{
+	{Letter: "p", Name: "package", Description: "packages"},
+	{Letter: "P", Name: "packageName", Description: "names for imported packages"},
+	{Letter: "f", Name: "func", Description: "functions and methods"},
+	{Letter: "v", Name: "var", Description: "variables"},
+	{Letter: "c", Name: "const", Description: "constants"},
+	{Letter: "t", Name: "type", Description: "types"},
+	{Letter: "s", Name: "struct", Description: "structs"},
+	{Letter: "i", Name: "interface", Description: "interfaces"},
+	{Letter: "m", Name: "member", Description: "struct members"},
+	{Letter: "n", Name: "methodSpec", Description: "interface method specifications"},
+	{Letter: "a", Name: "talias", Description: "type aliases"},
+	{Letter: kindTest, Name: "test", Description: "test functions"},
+	{Letter: kindBenchmark, Name: "benchmark", Description: "benchmark functions"},
+	{Letter: kindExample, Name: "example", Description: "example functions"},
+	{Letter: kindFuzz, Name: "fuzz", Description: "fuzz tests"},
+	{Letter: KindCallReference, Name: "callRef", Description: "references: calls of functions and methods"},
+	{Letter: kindTypeReference, Name: "typeRef", Description: "references: uses of types"},
+	{Letter: kindMemberReference, Name: "memberRef", Description: "references: uses of struct members and methods, other than calls"},
+	{Letter: kindIdentifierReference, Name: "identifierRef", Description: "references: uses of variables, constants, functions and packages, other than calls"},
+}
+
+// FunctionKinds are the letters of the kinds of the tags of functions and
+// methods.
+var FunctionKinds = []string{"f", kindTest, kindBenchmark, kindExample, kindFuzz}
@@ -1,56 +1,101 @@
 package golang
 
 import (
-	common "github.com/jha-naman/tree-tags/common"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
 	sitter "github.com/smacker/go-tree-sitter"
 )
 
-func (p *Processor) processConstDeclaration() {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
+// constGroup holds what a const spec can inherit from the specs before it in
+// the same declaration. A spec without values repeats the type and the values
+// of the last spec that has them, with iota being the index of the spec.
+type constGroup struct {
+	grouped  bool
+	iota     int64
+	typeName string
+	values   *sitter.Node
+}
+
+// constGroupOf returns what the const spec inherits from the specs before it
+// in its declaration.
+func (p *Processor) constGroupOf(specNode *sitter.Node) constGroup {
+	group := constGroup{}
 
-	p.processConstSpec()
+	declarationNode := specNode.Parent()
+	for i := 0; i < int(declarationNode.ChildCount()); i++ {
+		child := declarationNode.Child(i)
+		switch child.Type() {
+		case "(":
+			group.grouped = true
+		case "const_spec":
+			if values := child.ChildByFieldName("value"); values != nil {
+				group.typeName, group.values = p.childText(child, "type"), values
+			}
 
-	for cursor.GoToNextSibling() {
-		p.processConstSpec()
+			if child.StartByte() == specNode.StartByte() {
+				return group
+			}
+
+			group.iota++
+		}
 	}
+
+	return group
 }
 
-func (p *Processor) processConstSpec() {
-	cursor := p.cursor
-	identifierTags := []common.TagEntry{}
-
-	processIdentifier := func(node *sitter.Node) {
-		line := p.FileBytes[node.StartPoint().Row]
-		identifierTags = append(identifierTags, common.TagEntry{
-			Name:            string(line[node.StartPoint().Column:node.EndPoint().Column]),
-			FileName:        p.FileName,
-			Address:         p.addressStringFromBytes(line),
-			Kind:            "c",
-			ExtensionFields: map[string]string{"package": p.packageName},
-		})
-	}
+// Example tree:
+//
+//	(const_spec
+//	    name: (identifier)
+//	    name: (identifier)
+//	    type: (type_identifier)
+//	    value: (expression_list
+//	        (iota)
+//	        (binary_expression
+//	            left: (iota)
+//	            right: (int_literal))))
+func (p *Processor) processConstSpec(definitions []tagquery.Definition) {
+	specNode := definitions[0].Node
+	tags := p.definitionTags(definitions, "c")
+	group := p.constGroupOf(specNode)
 
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
+	for i, tag := range tags {
+		tag.ExtensionFields["package"] = p.packageName
 
-	node := cursor.CurrentNode()
-	if node.Type() == "identifier" {
-		processIdentifier(node)
+		if group.typeName != "" {
+			tag.ExtensionFields["typeref:typename"] = group.typeName
+			if group.grouped {
+				tag.ExtensionFields["enum"] = group.typeName
+			}
+		}
+
+		// the value of a name is the one at the same position in the values
+		valueIndex := nameIndex(specNode, definitions[i].Name)
+		if group.values == nil || valueIndex >= int(group.values.NamedChildCount()) {
+			continue
+		}
+
+		if value, ok := p.evaluateIotaExpression(group.values.NamedChild(valueIndex), group.iota); ok {
+			tag.ExtensionFields["value"] = value
+		}
 	}
 
-	for cursor.GoToNextSibling() {
-		node := cursor.CurrentNode()
-		switch node.Type() {
-		case "identifier":
-			processIdentifier(node)
+	p.setDocFields(tags, specNode)
+	p.Tags = append(p.Tags, tags...)
+}
+
+// nameIndex returns the position of the name among the names of the spec.
+func nameIndex(specNode, nameNode *sitter.Node) int {
+	index := 0
+	for i := 0; i < int(specNode.NamedChildCount()); i++ {
+		child := specNode.NamedChild(i)
+		if child.StartByte() == nameNode.StartByte() {
+			break
+		}
+
+		if child.Type() == nameNode.Type() {
+			index++
 		}
 	}
 
-	p.Tags = append(p.Tags, identifierTags...)
+	return index
 }
@@ -1,45 +1,33 @@
 package golang
 
 import (
-	common "github.com/jha-naman/tree-tags/common"
-)
-
-func (p *Processor) processFunctionDeclaration() {
-	cursor := p.cursor
-	parentNode := cursor.CurrentNode()
-
-	cursor.GoToFirstChild()
-	defer cursor.GoToParent()
+	"strings"
 
-	childCount := 1
-	var fnName, result string
-	var line []byte
-
-	for cursor.GoToNextSibling() {
-		currentNode := cursor.CurrentNode()
-
-		switch parentNode.FieldNameForChild(childCount) {
-		case "name":
-			line = p.FileBytes[currentNode.StartPoint().Row]
-			fnName = string(line[currentNode.StartPoint().Column:currentNode.EndPoint().Column])
-		case "result":
-			result = p.stringFromByteRange(p.FileBytes, currentNode.Range())
-		}
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
+)
 
-		childCount++
-	}
+func (p *Processor) processFunctionDeclaration(definition tagquery.Definition) {
+	node := definition.Node
+	tags := p.definitionTags([]tagquery.Definition{definition}, "f")
+	tag := tags[0]
 
-	tag := common.TagEntry{
-		Name:            fnName,
-		FileName:        p.FileName,
-		Address:         p.addressStringFromBytes(line),
-		Kind:            "f",
-		ExtensionFields: map[string]string{"package": p.packageName},
-	}
+	tag.ExtensionFields["package"] = p.packageName
+	tag.ExtensionFields["signature"] = p.childText(node, "parameters")
 
+	result := p.childText(node, "result")
 	if result != "" {
 		tag.ExtensionFields["typeref:typename"] = result
 	}
 
-	p.Tags = append(p.Tags, tag)
+	if strings.HasSuffix(p.FileName, "_test.go") {
+		if kind, target := testFunctionKind(tag.Name, p.childText(node, "parameters"), result, p.testingName); kind != "" {
+			tags[0].Kind = kind
+			if target != "" {
+				tag.ExtensionFields["testof"] = target
+			}
+		}
+	}
+
+	p.setDocFields(tags, node)
+	p.Tags = append(p.Tags, tags...)
 }
@@ -1,65 +1,24 @@
 package golang
 
 import (
-	common "github.com/jha-naman/tree-tags/common"
-)
-
-func (p *Processor) processImportDeclaration() {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	if !cursor.GoToNextSibling() {
-		return
-	}
-
-	switch cursor.CurrentNode().Type() {
-	case "import_spec":
-		p.processImportSpec()
-	case "import_spec_list":
-		if !cursor.GoToFirstChild() {
-			break
-		}
-		defer cursor.GoToParent()
+	"strings"
 
-		p.processImportSpec()
-		for cursor.GoToNextSibling() {
-			p.processImportSpec()
-		}
-	}
-}
-
-func (p *Processor) processImportSpec() {
-	var tag common.TagEntry
-	cursor := p.cursor
-	node := cursor.CurrentNode()
-
-	if node.Type() != "import_spec" || !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	node = cursor.CurrentNode()
-	line := p.FileBytes[node.StartPoint().Row]
-	if node.Type() != "package_identifier" {
-		return
-	}
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
+)
 
-	tag = common.TagEntry{
-		Name:     string(line[node.StartPoint().Column:node.EndPoint().Column]),
-		Kind:     "P",
-		Address:  p.addressStringFromBytes(line),
-		FileName: p.FileName,
-	}
+// processImportSpec adds the tag for the name an import is given, e.g. 'assert'
+// for 'import assert "github.com/stretchr/testify/assert"', with the import
+// path in the 'package' field. The name of the 'testing' package is kept for
+// the parameters of test functions.
+func (p *Processor) processImportSpec(definition tagquery.Definition) {
+	tags := p.definitionTags([]tagquery.Definition{definition}, "P")
 
-	cursor.GoToNextSibling()
-	node = cursor.CurrentNode()
+	importPath := strings.Trim(p.childText(definition.Node, "path"), "\"`")
+	tags[0].ExtensionFields["package"] = importPath
 
-	tag.ExtensionFields = map[string]string{
-		"package": string(line[node.StartPoint().Column+1 : node.EndPoint().Column-1]),
+	if importPath == "testing" {
+		p.testingName = tags[0].Name
 	}
 
-	p.Tags = append(p.Tags, tag)
+	p.Tags = append(p.Tags, tags...)
 }
@@ -3,77 +3,35 @@ package golang
 import (
 	"fmt"
 
-	common "github.com/jha-naman/tree-tags/common"
-	sitter "github.com/smacker/go-tree-sitter"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
 )
 
-func (p *Processor) processMethodDeclaration() {
-	cursor := p.cursor
-	parentNode := cursor.CurrentNode()
-	if !cursor.GoToFirstChild() {
-		return
+// Example tree:
+//
+//	(method_declaration
+//	    receiver: (parameter_list
+//	        (parameter_declaration
+//	            name: (identifier)
+//	            type: (pointer_type (type_identifier))))
+//	    name: (field_identifier)
+//	    parameters: (parameter_list)
+//	    result: (type_identifier)
+//	    body: (block))
+func (p *Processor) processMethodDeclaration(definition tagquery.Definition) {
+	node := definition.Node
+	tags := p.definitionTags([]tagquery.Definition{definition}, "f")
+	tags[0].ExtensionFields["signature"] = p.childText(node, "parameters")
+
+	if result := p.childText(node, "result"); result != "" {
+		tags[0].ExtensionFields["typeref:typename"] = result
 	}
-	defer cursor.GoToParent()
 
-	var name, address, typerefName, receiverType string
-
-	processReceiver := func(node *sitter.Node) string {
-		if !cursor.GoToFirstChild() {
-			return ""
-		}
-		defer cursor.GoToParent()
-
-		cursor.GoToNextSibling()
-
-		node = cursor.CurrentNode()
-		if !cursor.GoToFirstChild() {
-			return ""
+	if receiver := node.ChildByFieldName("receiver"); receiver != nil && receiver.NamedChildCount() > 0 {
+		if receiverType := p.childText(receiver.NamedChild(0), "type"); receiverType != "" {
+			tags[0].ExtensionFields["unkown"] = fmt.Sprintf("%s.%s", p.packageName, receiverType)
 		}
-		defer cursor.GoToParent()
-
-		count := 0
-		for cursor.GoToNextSibling() {
-			count++
-			childNode := cursor.CurrentNode()
-			if node.FieldNameForChild(count) == "type" {
-				return p.stringFromByteRange(p.FileBytes, childNode.Range())
-			}
-		}
-		return ""
-	}
-
-	childCount := 1
-	for cursor.GoToNextSibling() {
-		node := cursor.CurrentNode()
-		switch parentNode.FieldNameForChild(childCount) {
-		case "name":
-			name = p.stringFromByteRange(p.FileBytes, node.Range())
-			address = p.addressStringFromBytes(p.FileBytes[node.StartPoint().Row])
-		case "receiver":
-			receiverType = processReceiver(node)
-		case "result":
-			typerefName = p.stringFromByteRange(p.FileBytes, node.Range())
-		case "body":
-			break
-		}
-		childCount++
-	}
-
-	tagEntry := common.TagEntry{
-		Name:            name,
-		FileName:        p.FileName,
-		Address:         address,
-		Kind:            "f",
-		ExtensionFields: map[string]string{},
-	}
-
-	if typerefName != "" {
-		tagEntry.ExtensionFields["typeref:typename"] = typerefName
-	}
-
-	if receiverType != "" {
-		tagEntry.ExtensionFields["unkown"] = fmt.Sprintf("%s.%s", p.packageName, receiverType)
 	}
 
-	p.Tags = append(p.Tags, tagEntry)
+	p.setDocFields(tags, node)
+	p.Tags = append(p.Tags, tags...)
 }
@@ -1,30 +1,13 @@
 package golang
 
 import (
-	common "github.com/jha-naman/tree-tags/common"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
 )
 
-func (p *Processor) processPackageDeclaration() {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
+func (p *Processor) processPackageClause(definition tagquery.Definition) {
+	tags := p.definitionTags([]tagquery.Definition{definition}, "p")
+	p.packageName = tags[0].Name
 
-	if !cursor.GoToNextSibling() {
-		return
-	}
-
-	node := cursor.CurrentNode()
-	lineBytes := p.FileBytes[node.StartPoint().Row]
-	p.packageName = string(lineBytes[node.StartPoint().Column:node.EndPoint().Column])
-
-	tag := common.TagEntry{
-		Name:     p.packageName,
-		FileName: p.FileName,
-		Address:  p.addressStringFromBytes(lineBytes),
-		Kind:     "p",
-	}
-
-	p.Tags = append(p.Tags, tag)
+	p.setDocFields(tags, definition.Node)
+	p.Tags = append(p.Tags, tags...)
 }
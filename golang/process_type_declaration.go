@@ -2,255 +2,126 @@ package golang
 
 import (
 	"fmt"
+	"strings"
 
-	common "github.com/jha-naman/tree-tags/common"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
+	sitter "github.com/smacker/go-tree-sitter"
 )
 
-// Example tree:
-//
-//	(type_declaration
-//	    (type_spec
-//	        name: (type_identifier)
-//	        type: (struct_type
-//	            (field_declaration_list
-//	                (field_declaration
-//	                    name: (field_identifier)
-//	                    name: (field_identifier)
-//	                    type: (type_identifier))
-//	                (field_declaration
-//	                    name: (field_identifier)
-//	                    type: (type_identifier))))))
-func (p *Processor) processTypeDeclaration() {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	for cursor.GoToNextSibling() {
-		switch cursor.CurrentNode().Type() {
-		case "type_spec":
-			p.processTypeSpec()
-		case "type_alias":
-			p.processTypeAlias()
-		}
-	}
+// kinds of the tags for the kinds of types matched by the go tags query
+var typeKindLetters = map[string]string{
+	"type":      "t",
+	"struct":    "s",
+	"interface": "i",
+	"talias":    "a",
 }
 
-func (p *Processor) processTypeSpec() {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	node := cursor.CurrentNode()
-	typeName := string(p.FileBytes[node.StartPoint().Row][node.StartPoint().Column:node.EndPoint().Column])
-
-	for cursor.GoToNextSibling() {
-		node = cursor.CurrentNode()
-		switch node.Type() {
-		case "type_identifier":
-			parentNode := node.Parent()
-			p.Tags = append(p.Tags, common.TagEntry{
-				Name:            typeName,
-				FileName:        p.FileName,
-				Address:         p.addressStringFromBytes(p.FileBytes[parentNode.StartPoint().Row]),
-				Kind:            "t",
-				ExtensionFields: map[string]string{"package": p.packageName, "typeref:typename": p.stringFromByteRange(p.FileBytes, node.Range())},
-			})
-		case "struct_type":
-			parentNode := node.Parent()
-			p.Tags = append(p.Tags, common.TagEntry{
-				Name:            typeName,
-				FileName:        p.FileName,
-				Address:         p.addressStringFromBytes(p.FileBytes[parentNode.StartPoint().Row]),
-				Kind:            "s",
-				ExtensionFields: map[string]string{"package": p.packageName},
-			})
-			p.processStructType(typeName)
-		case "interface_type":
-			parentNode := node.Parent()
-			p.Tags = append(p.Tags, common.TagEntry{
-				Name:            typeName,
-				FileName:        p.FileName,
-				Address:         p.addressStringFromBytes(p.FileBytes[parentNode.StartPoint().Row]),
-				Kind:            "i",
-				ExtensionFields: map[string]string{"package": p.packageName},
-			})
-			p.processInterfaceMethods(typeName)
-		default:
-			parentNode := node.Parent()
-			p.Tags = append(p.Tags, common.TagEntry{
-				Name:            typeName,
-				FileName:        p.FileName,
-				Address:         p.addressStringFromBytes(p.FileBytes[parentNode.StartPoint().Row]),
-				Kind:            "a",
-				ExtensionFields: map[string]string{"package": p.packageName, "typeref:typename": p.stringFromByteRange(p.FileBytes, node.Range())},
-			})
+// processTypeSpec adds the tag for a type spec or a type alias. Named types
+// and aliases get the type they are defined as in the 'typeref' field, and
+// interfaces the types they embed in the 'embeds' field.
+func (p *Processor) processTypeSpec(definition tagquery.Definition) {
+	tags := p.definitionTags([]tagquery.Definition{definition}, typeKindLetters[definition.Kind])
+	tags[0].ExtensionFields["package"] = p.packageName
+
+	switch definition.Kind {
+	case "type", "talias":
+		tags[0].ExtensionFields["typeref:typename"] = p.childText(definition.Node, "type")
+	case "interface":
+		if embeds := p.embeddedTypes(definition.Node.ChildByFieldName("type")); len(embeds) > 0 {
+			tags[0].ExtensionFields["embeds"] = strings.Join(embeds, ",")
 		}
 	}
-}
-
-func (p *Processor) processTypeAlias() {
-	cursor := p.cursor
-	parentNode := cursor.CurrentNode()
-	childCount := 0
-	var typeName, aliasedTypeName, address string
-
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
 
-	node := cursor.CurrentNode()
-	typeName = string(p.FileBytes[node.StartPoint().Row][node.StartPoint().Column:node.EndPoint().Column])
-	address = p.addressStringFromBytes(p.FileBytes[node.StartPoint().Row])
+	p.setDocFields(tags, definition.Node)
+	p.Tags = append(p.Tags, tags...)
+}
 
-	for cursor.GoToNextSibling() {
-		childCount++
-		node = cursor.CurrentNode()
-		if parentNode.FieldNameForChild(childCount) == "type" {
-			aliasedTypeName = p.stringFromByteRange(p.FileBytes, node.Range())
-			break
+// Example tree:
+//
+//	(interface_type
+//	    (type_elem
+//	        (qualified_type
+//	            package: (package_identifier)
+//	            name: (type_identifier)))
+//	    (method_elem
+//	        name: (field_identifier)
+//	        parameters: (parameter_list)))
+func (p *Processor) embeddedTypes(interfaceNode *sitter.Node) []string {
+	var embeds []string
+	for i := 0; i < int(interfaceNode.NamedChildCount()); i++ {
+		if child := interfaceNode.NamedChild(i); child.Type() == "type_elem" {
+			embeds = append(embeds, p.stringFromByteRange(p.FileBytes, child.Range()))
 		}
 	}
 
-	p.Tags = append(p.Tags, common.TagEntry{
-		Name:            typeName,
-		FileName:        p.FileName,
-		Address:         address,
-		Kind:            "a",
-		ExtensionFields: map[string]string{"package": p.packageName, "typeref:typename": aliasedTypeName},
-	})
+	return embeds
 }
 
 // Example tree:
 //
-//	(struct_type
-//	    (field_declaration_list
-//	        (field_declaration
-//	            name: (field_identifier)
-//	            name: (field_identifier)
-//	            type: (type_identifier))
-//	        (field_declaration
-//	            name: (field_identifier)
-//	            type: (type_identifier))))
-func (p *Processor) processStructType(typeName string) {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	// move cursor  to the 'field_declaration_list' node
-	if !cursor.GoToNextSibling() {
-		return
-	}
-
-	if !cursor.GoToFirstChild() {
-		return
+//	(type_spec
+//	    name: (type_identifier)
+//	    type: (struct_type
+//	        (field_declaration_list
+//	            (field_declaration
+//	                name: (field_identifier)
+//	                name: (field_identifier)
+//	                type: (type_identifier)
+//	                tag: (raw_string_literal)))))
+func (p *Processor) processFieldDeclaration(definitions []tagquery.Definition) {
+	fieldNode := definitions[0].Node
+	tags := p.definitionTags(definitions, "m")
+
+	// field_declaration -> field_declaration_list -> struct_type -> type_spec
+	typeName := p.enclosingTypeName(fieldNode, 3)
+	typeString := p.childText(fieldNode, "type")
+	for _, tag := range tags {
+		tag.ExtensionFields["struct"] = fmt.Sprintf("%s.%s", p.packageName, typeName)
+		tag.ExtensionFields["typeref:typename"] = typeString
 	}
-	defer cursor.GoToParent()
-
-	p.processFieldDeclaration(typeName)
 
-	for cursor.GoToNextSibling() {
-		p.processFieldDeclaration(typeName)
-	}
+	p.setDocFields(tags, fieldNode)
+	aliasTags := p.setStructTagFields(tags, p.childText(fieldNode, "tag"))
+	p.Tags = append(p.Tags, tags...)
+	p.Tags = append(p.Tags, aliasTags...)
 }
 
-func (p *Processor) processFieldDeclaration(typeName string) {
-	cursor := p.cursor
-	parentNode := cursor.CurrentNode()
-
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	childCount := 0
-	structFieldTags := []common.TagEntry{}
-	var typeString string
-
-	if parentNode.FieldNameForChild(childCount) == "name" {
-		structFieldTags = append(structFieldTags, p.processFieldIdentifier(typeName))
-	}
+// Example tree:
+//
+//	(type_spec
+//	    name: (type_identifier)
+//	    type: (interface_type
+//	        (method_elem
+//	            name: (field_identifier)
+//	            parameters: (parameter_list)
+//	            result: (type_identifier))))
+func (p *Processor) processMethodSpec(definition tagquery.Definition) {
+	methodNode := definition.Node
+	tags := p.definitionTags([]tagquery.Definition{definition}, "n")
 
-	for cursor.GoToNextSibling() {
-		childCount++
-		node := cursor.CurrentNode()
-		switch parentNode.FieldNameForChild(childCount) {
-		case "name":
-			structFieldTags = append(structFieldTags, p.processFieldIdentifier(typeName))
-		case "type":
-			typeString = p.stringFromByteRange(p.FileBytes, node.Range())
-		}
-	}
+	// method_elem -> interface_type -> type_spec
+	typeName := p.enclosingTypeName(methodNode, 2)
+	tags[0].ExtensionFields["interface"] = fmt.Sprintf("%s.%s", p.packageName, typeName)
+	tags[0].ExtensionFields["signature"] = p.childText(methodNode, "parameters")
 
-	for _, tag := range structFieldTags {
-		tag.ExtensionFields["typeref:typename"] = typeString
+	if result := p.childText(methodNode, "result"); result != "" {
+		tags[0].ExtensionFields["typeref:typename"] = result
 	}
 
-	p.Tags = append(p.Tags, structFieldTags...)
+	p.setDocFields(tags, methodNode)
+	p.Tags = append(p.Tags, tags...)
 }
 
-func (p *Processor) processFieldIdentifier(typeName string) common.TagEntry {
-	node := p.cursor.CurrentNode()
-	line := p.FileBytes[node.StartPoint().Row]
-	return common.TagEntry{
-		Name:            string(line[node.StartPoint().Column:node.EndPoint().Column]),
-		FileName:        p.FileName,
-		Address:         p.addressStringFromBytes(line),
-		Kind:            "m",
-		ExtensionFields: map[string]string{"struct": fmt.Sprintf("%s.%s", p.packageName, typeName)},
+// enclosingTypeName returns the name of the type spec the given number of
+// levels above the node.
+func (p *Processor) enclosingTypeName(node *sitter.Node, levels int) string {
+	for i := 0; i < levels && node != nil; i++ {
+		node = node.Parent()
 	}
-}
 
-func (p *Processor) processInterfaceMethods(typeName string) {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
+	if node == nil {
+		return ""
 	}
-	defer cursor.GoToParent()
 
-	for cursor.GoToNextSibling() {
-		parentNode := cursor.CurrentNode()
-		if parentNode.Type() != "method_elem" {
-			continue
-		}
-
-		if !cursor.GoToFirstChild() {
-			continue
-		}
-		defer cursor.GoToParent()
-
-		node := cursor.CurrentNode()
-		childCount := 0
-		fnName, resultStr := p.stringFromByteRange(p.FileBytes, node.Range()), ""
-		line := p.FileBytes[node.StartPoint().Row]
-
-		for cursor.GoToNextSibling() {
-			childCount++
-			switch parentNode.FieldNameForChild(childCount) {
-			case "parameters":
-			case "result":
-				resultStr = p.stringFromByteRange(p.FileBytes, cursor.CurrentNode().Range())
-			}
-		}
-
-		tag := common.TagEntry{
-			Name:            fnName,
-			FileName:        p.FileName,
-			Address:         p.addressStringFromBytes(line),
-			Kind:            "n",
-			ExtensionFields: map[string]string{"interface": fmt.Sprintf("%s.%s", p.packageName, typeName)},
-		}
-
-		if resultStr != "" {
-			tag.ExtensionFields["typeref:typename"] = resultStr
-		}
-
-		p.Tags = append(p.Tags, tag)
-	}
+	return p.childText(node, "name")
 }
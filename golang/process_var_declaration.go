@@ -1,82 +1,23 @@
 package golang
 
 import (
-	common "github.com/jha-naman/tree-tags/common"
-	sitter "github.com/smacker/go-tree-sitter"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
 )
 
-func (p *Processor) processVarDeclaration() {
-	cursor := p.cursor
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	p.processVarSpec()
-
-	for cursor.GoToNextSibling() {
-		switch cursor.CurrentNode().Type() {
-		case "var_spec":
-			p.processVarSpec()
-		case "var_spec_list":
-			if !cursor.GoToFirstChild() {
-				continue
-			}
-			defer cursor.GoToParent()
-
-			p.processVarSpec()
-			for cursor.GoToNextSibling() {
-				p.processVarSpec()
-			}
-		}
-	}
-}
-
-func (p *Processor) processVarSpec() {
-	identifierTags := []common.TagEntry{}
-	var typeIdentifier string
-
-	processIdentifier := func(node *sitter.Node) {
-		line := p.FileBytes[node.StartPoint().Row]
-		identifierTags = append(identifierTags, common.TagEntry{
-			Name:            string(line[node.StartPoint().Column:node.EndPoint().Column]),
-			FileName:        p.FileName,
-			Address:         p.addressStringFromBytes(line),
-			Kind:            "v",
-			ExtensionFields: map[string]string{"package": p.packageName},
-		})
-	}
-
-	cursor := p.cursor
-	childCount := 0
-	parentNode := cursor.CurrentNode()
-	if !cursor.GoToFirstChild() {
-		return
-	}
-	defer cursor.GoToParent()
-
-	node := cursor.CurrentNode()
-	if parentNode.FieldNameForChild(childCount) == "name" {
-		processIdentifier(node)
-	}
-
-	for cursor.GoToNextSibling() {
-		childCount++
-
-		node = cursor.CurrentNode()
-		switch parentNode.FieldNameForChild(childCount) {
-		case "name":
-			processIdentifier(node)
-		case "type":
-			typeIdentifier = p.stringFromByteRange(p.FileBytes, node.Range())
-		}
-	}
-
-	if typeIdentifier != "" {
-		for _, tag := range identifierTags {
-			tag.ExtensionFields["typeref:typename"] = typeIdentifier
+// processVarSpec adds the tags for the names of a var spec, which all share
+// its type.
+func (p *Processor) processVarSpec(definitions []tagquery.Definition) {
+	specNode := definitions[0].Node
+	tags := p.definitionTags(definitions, "v")
+
+	typeName := p.childText(specNode, "type")
+	for _, tag := range tags {
+		tag.ExtensionFields["package"] = p.packageName
+		if typeName != "" {
+			tag.ExtensionFields["typeref:typename"] = typeName
 		}
 	}
 
-	p.Tags = append(p.Tags, identifierTags...)
+	p.setDocFields(tags, specNode)
+	p.Tags = append(p.Tags, tags...)
 }
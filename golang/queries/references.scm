@@ -0,0 +1,14 @@
+; References query for go files, run on the bodies of functions and methods.
+; The kinds after 'reference.' are the names of the reference kinds in
+; kinds.go. Calls and selectors come first, so that their names are not also
+; matched as plain identifiers or members.
+
+(call_expression function: (identifier) @name) @reference.callRef
+(call_expression
+  function: (selector_expression field: (field_identifier) @name)) @reference.callRef
+
+(type_identifier) @name @reference.typeRef
+
+(selector_expression field: (field_identifier) @name) @reference.memberRef
+
+(identifier) @name @reference.identifierRef
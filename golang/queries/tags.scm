@@ -0,0 +1,64 @@
+; Tags query for go files. The kinds after 'definition.' are the names of the
+; kinds in kinds.go, the go code adds the scopes, types and other fields.
+; Only top level declarations are tagged, not the ones inside function bodies.
+
+(source_file
+  (package_clause (package_identifier) @name) @definition.package)
+
+(source_file
+  (import_declaration
+    (import_spec name: (package_identifier) @name) @definition.packageName))
+(source_file
+  (import_declaration
+    (import_spec_list
+      (import_spec name: (package_identifier) @name) @definition.packageName)))
+
+(source_file
+  (function_declaration name: (identifier) @name) @definition.func)
+(source_file
+  (method_declaration name: (field_identifier) @name) @definition.func)
+
+; the names of var and const specs are matched without the 'name' field, as
+; not all of them are matched with it
+(source_file
+  (var_declaration
+    (var_spec (identifier) @name) @definition.var))
+(source_file
+  (var_declaration
+    (var_spec_list
+      (var_spec (identifier) @name) @definition.var)))
+
+(source_file
+  (const_declaration
+    (const_spec (identifier) @name) @definition.const))
+
+; the first pattern matching a name wins, so the last type_spec pattern only
+; applies to types which are not structs, interfaces or named types
+(source_file
+  (type_declaration
+    (type_spec name: (type_identifier) @name type: (struct_type)) @definition.struct))
+(source_file
+  (type_declaration
+    (type_spec name: (type_identifier) @name type: (interface_type)) @definition.interface))
+(source_file
+  (type_declaration
+    (type_spec name: (type_identifier) @name type: (type_identifier)) @definition.type))
+(source_file
+  (type_declaration
+    (type_spec name: (type_identifier) @name) @definition.talias))
+(source_file
+  (type_declaration
+    (type_alias name: (type_identifier) @name) @definition.talias))
+
+(source_file
+  (type_declaration
+    (type_spec
+      type: (struct_type
+        (field_declaration_list
+          (field_declaration (field_identifier) @name) @definition.member)))))
+
+(source_file
+  (type_declaration
+    (type_spec
+      type: (interface_type
+        (method_elem name: (field_identifier) @name) @definition.methodSpec))))
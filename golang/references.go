@@ -0,0 +1,330 @@
+package golang
+
+import (
+	_ "embed"
+	"go/token"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+
+	common "github.com/jha-naman/tree-tags/common"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+)
+
+// letters of the kinds of the reference tags, which are kinds of their own so
+// that selecting the kinds of definitions does not select references and the
+// kind tells what is used
+const (
+	// KindCallReference is the kind of the reference tags of calls.
+	KindCallReference       = "C"
+	kindTypeReference       = "Y"
+	kindMemberReference     = "M"
+	kindIdentifierReference = "I"
+)
+
+//go:embed queries/references.scm
+var referencesQuerySource []byte
+
+// referencesQuery is the query finding the references in function bodies.
+var referencesQuery = sync.OnceValue(func() *tagquery.Query {
+	query, err := tagquery.NewReferencesQuery(referencesQuerySource, golang.GetLanguage())
+	if err != nil {
+		log.Fatal("error in the go references query:", err.Error())
+	}
+
+	return query
+})
+
+// scopeNodeTypes are the types of the nodes names declared in them are
+// visible in.
+var scopeNodeTypes = []string{
+	"function_declaration", "method_declaration", "func_literal", "block", "if_statement", "for_statement",
+	"expression_switch_statement", "type_switch_statement", "select_statement", "expression_case", "type_case",
+	"default_case", "communication_case",
+}
+
+// localDeclaration is a name declared in a function, visible in the scope node
+// from the visibleFrom byte on.
+type localDeclaration struct {
+	scope       *sitter.Node
+	visibleFrom uint32
+}
+
+// referenceTags returns the reference tags, having the 'roles:ref' field, for
+// the uses of identifiers, calls and types in the bodies of the functions and
+// methods of the file, scoped by the function they are in. The uses of names
+// declared in the function get the 'local' field. The names used with a
+// selector, like 'Println' in 'fmt.Println', get the operand as 'qualifier',
+// and the import path of the package when the operand names an import of the
+// file, as do the uses of the import names themselves.
+func (p *Processor) referenceTags(root *sitter.Node, source []byte) []common.TagEntry {
+	var tags []common.TagEntry
+
+	imports := fileImports(root, source)
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		node := root.NamedChild(i)
+		body := node.ChildByFieldName("body")
+		if body == nil || (node.Type() != "function_declaration" && node.Type() != "method_declaration") {
+			continue
+		}
+
+		scope := p.functionScope(node)
+		locals := map[string][]localDeclaration{}
+		collectLocalDeclarations(node, source, locals)
+
+		for _, reference := range referencesQuery().References(body, source) {
+			nameNode := reference.Name
+			if isDeclaredName(nameNode) {
+				continue
+			}
+
+			name := p.stringFromByteRange(p.FileBytes, nameNode.Range())
+			if name == "_" {
+				continue
+			}
+
+			// the access of the name used, so that the 'exported-only' option
+			// keeps the uses of exported identifiers
+			access := "private"
+			if token.IsExported(name) {
+				access = "public"
+			}
+
+			fields := map[string]string{
+				"line":   lineNumberOf(nameNode),
+				"func":   scope,
+				"access": access,
+				"roles":  "ref",
+			}
+
+			if operand := selectorOperand(nameNode); operand != nil {
+				qualifier := operand.Content(source)
+				fields["qualifier"] = strings.Join(strings.Fields(qualifier), " ")
+
+				if importPath, ok := imports[qualifier]; ok && !isLocal(operand, qualifier, locals) {
+					fields["import"] = importPath
+				}
+			} else if isLocal(nameNode, name, locals) {
+				fields["local"] = "yes"
+			} else if importPath, ok := imports[name]; ok && nameNode.Type() == "identifier" {
+				fields["import"] = importPath
+			}
+
+			tags = append(tags, common.TagEntry{
+				Name:            name,
+				FileName:        p.FileName,
+				Address:         p.addressStringFromBytes(p.FileBytes[nameNode.StartPoint().Row]),
+				Kind:            common.KindLetter(Kinds, reference.Kind),
+				ExtensionFields: fields,
+				Column:          p.columnOf(nameNode),
+			})
+		}
+	}
+
+	return tags
+}
+
+// functionScope returns the scope of the references in the body of the
+// function or method, e.g. 'main.run' or 'main.Server.Start'.
+func (p *Processor) functionScope(node *sitter.Node) string {
+	name := p.childText(node, "name")
+
+	receiver := node.ChildByFieldName("receiver")
+	if receiver == nil || receiver.NamedChildCount() == 0 {
+		return p.packageName + "." + name
+	}
+
+	receiverType := strings.TrimPrefix(p.childText(receiver.NamedChild(0), "type"), "*")
+	receiverType, _, _ = strings.Cut(receiverType, "[")
+
+	return p.packageName + "." + receiverType + "." + name
+}
+
+// selectorOperand returns the operand of the selector the name is selected
+// with, like 'fmt' for 'Println' in 'fmt.Println' or for 'Stringer' in
+// 'fmt.Stringer', or nil when the name is not selected.
+func selectorOperand(nameNode *sitter.Node) *sitter.Node {
+	parent := nameNode.Parent()
+	if parent == nil {
+		return nil
+	}
+
+	switch parent.Type() {
+	case "selector_expression":
+		if sameNode(parent.ChildByFieldName("field"), nameNode) {
+			return parent.ChildByFieldName("operand")
+		}
+	case "qualified_type":
+		if sameNode(parent.ChildByFieldName("name"), nameNode) {
+			return parent.ChildByFieldName("package")
+		}
+	}
+
+	return nil
+}
+
+// fileImports returns the import paths of the file by the names they are
+// imported as. Imports without a name are taken to be named after the last
+// element of their path, without a version suffix like '.v3' or a 'go-'
+// prefix, which is the name of most packages.
+func fileImports(root *sitter.Node, source []byte) map[string]string {
+	imports := map[string]string{}
+
+	var collect func(node *sitter.Node)
+	collect = func(node *sitter.Node) {
+		switch node.Type() {
+		case "import_declaration", "import_spec_list":
+			for i := 0; i < int(node.NamedChildCount()); i++ {
+				collect(node.NamedChild(i))
+			}
+		case "import_spec":
+			pathNode := node.ChildByFieldName("path")
+			if pathNode == nil {
+				return
+			}
+
+			importPath := strings.Trim(pathNode.Content(source), "\"`")
+			name := defaultImportName(importPath)
+			if nameNode := node.ChildByFieldName("name"); nameNode != nil {
+				name = nameNode.Content(source)
+			}
+
+			if name != "_" && name != "." {
+				imports[name] = importPath
+			}
+		}
+	}
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		collect(root.NamedChild(i))
+	}
+
+	return imports
+}
+
+func defaultImportName(importPath string) string {
+	elements := strings.Split(importPath, "/")
+	name := elements[len(elements)-1]
+
+	// major version suffixes of module paths, like 'example.com/m/v2'
+	if len(elements) > 1 && len(name) > 1 && name[0] == 'v' && strings.Trim(name[1:], "0123456789") == "" {
+		name = elements[len(elements)-2]
+	}
+
+	name, _, _ = strings.Cut(name, ".")
+
+	return strings.TrimPrefix(name, "go-")
+}
+
+// collectLocalDeclarations adds the names declared in the function node, by
+// its parameters, receiver, results and type parameters and in its body, to
+// the declarations by name.
+func collectLocalDeclarations(node *sitter.Node, source []byte, locals map[string][]localDeclaration) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+
+		isTypeParameter := node.Type() == "type_parameter_declaration" && child.Type() == "identifier"
+		if name := child.Content(source); name != "_" && (isDeclaredName(child) || isTypeParameter) {
+			if scope := enclosingScope(child); scope != nil {
+				locals[name] = append(locals[name], localDeclaration{scope: scope, visibleFrom: visibleFrom(child)})
+			}
+		}
+
+		collectLocalDeclarations(child, source, locals)
+	}
+}
+
+// enclosingScope returns the closest node the names declared by the node are
+// visible in.
+func enclosingScope(node *sitter.Node) *sitter.Node {
+	for scope := node.Parent(); scope != nil; scope = scope.Parent() {
+		if slices.Contains(scopeNodeTypes, scope.Type()) {
+			return scope
+		}
+	}
+
+	return nil
+}
+
+// visibleFrom returns the byte from which on the name declared by the node can
+// be used: variables and constants are visible after their declaration, so
+// that e.g. 'x := x + 1' refers to an outer 'x' on the right, parameters and
+// types from their name on.
+func visibleFrom(nameNode *sitter.Node) uint32 {
+	parent := nameNode.Parent()
+
+	switch parent.Type() {
+	case "expression_list":
+		// the names on the left of ':=', also in range clauses, whose
+		// variables are visible in the body of the for statement
+		if declaration := parent.Parent(); declaration != nil {
+			return declaration.EndByte()
+		}
+	case "var_spec", "const_spec":
+		return parent.EndByte()
+	}
+
+	return nameNode.StartByte()
+}
+
+// isLocal reports whether the name used at the node refers to a declaration
+// of the function.
+func isLocal(node *sitter.Node, name string, locals map[string][]localDeclaration) bool {
+	for _, declaration := range locals[name] {
+		if declaration.scope.StartByte() <= node.StartByte() && node.EndByte() <= declaration.scope.EndByte() && node.StartByte() >= declaration.visibleFrom {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDeclaredName reports whether the name node declares a local variable,
+// constant, parameter or type instead of referring to one.
+func isDeclaredName(node *sitter.Node) bool {
+	parent := node.Parent()
+	if parent == nil {
+		return false
+	}
+
+	switch parent.Type() {
+	case "var_spec", "const_spec", "parameter_declaration", "variadic_parameter_declaration":
+		return node.Type() == "identifier"
+	case "type_spec", "type_alias":
+		return node.Type() == "type_identifier" && sameNode(parent.ChildByFieldName("name"), node)
+	case "expression_list":
+		// the names on the left of ':=', also in range clauses
+		declaration := parent.Parent()
+		if declaration == nil || !sameNode(declaration.ChildByFieldName("left"), parent) {
+			return false
+		}
+
+		switch declaration.Type() {
+		case "short_var_declaration":
+			return true
+		case "range_clause":
+			return hasChildOfType(declaration, ":=")
+		}
+	}
+
+	return false
+}
+
+func sameNode(a, b *sitter.Node) bool {
+	return a != nil && b != nil && a.StartByte() == b.StartByte() && a.EndByte() == b.EndByte() && a.Type() == b.Type()
+}
+
+func hasChildOfType(node *sitter.Node, nodeType string) bool {
+	for i := 0; i < int(node.ChildCount()); i++ {
+		if node.Child(i).Type() == nodeType {
+			return true
+		}
+	}
+
+	return false
+}
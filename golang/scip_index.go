@@ -0,0 +1,214 @@
+package golang
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+	scip "github.com/jha-naman/tree-tags/scip"
+)
+
+// scipDefinition is a definition references can be resolved to. Members are
+// the struct fields, interface methods and methods.
+type scipDefinition struct {
+	symbol     string
+	importPath string
+	kind       string
+	member     bool
+}
+
+// SCIPDocuments returns the SCIP documents for the go files, whose names are
+// relative to the root of the module, with the occurrences of the tagged
+// definitions and of the references in function bodies. Symbols are named by
+// the import paths of the packages, from the path of the module and the
+// directories of the files. References are resolved by name to definitions
+// of a matching kind, see resolveReference, and left out when that does not
+// single out one definition.
+func SCIPDocuments(modulePath, version string, fileNames []string, options common.Options) ([]scip.Document, error) {
+	options.References = true
+	options.Columns = true
+
+	documents := make([]scip.Document, len(fileNames))
+	fileTags := make([][]common.TagEntry, len(fileNames))
+	definitions := map[string][]scipDefinition{}
+
+	for i, fileName := range fileNames {
+		content, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		p := NewProcessorFromBytes(filepath.ToSlash(fileName), content, options)
+		fileTags[i] = p.GetTags()
+		documents[i] = scip.Document{Language: "go", RelativePath: filepath.ToSlash(fileName)}
+
+		importPath := packageImportPath(modulePath, fileName)
+		seen := map[string]bool{}
+		for _, tag := range fileTags[i] {
+			occurrenceRange, ok := nameRange(tag)
+			if tag.ExtensionFields["roles"] != "" || !ok {
+				continue
+			}
+
+			descriptors := scipDescriptors(tag, importPath)
+			if descriptors == nil {
+				continue
+			}
+
+			symbol := scip.Symbol("scip-go", "gomod", modulePath, version, descriptors...)
+			documents[i].Occurrences = append(documents[i].Occurrences, scip.Occurrence{
+				Range:       occurrenceRange,
+				Symbol:      symbol,
+				SymbolRoles: scip.SymbolRoleDefinition,
+			})
+
+			if !seen[symbol] {
+				seen[symbol] = true
+				documents[i].Symbols = append(documents[i].Symbols, scipSymbolInformation(tag, symbol, options.FullDoc))
+				definitions[tag.Name] = append(definitions[tag.Name], scipDefinition{
+					symbol:     symbol,
+					importPath: importPath,
+					kind:       tag.Kind,
+					member:     slices.Contains([]string{"m", "n"}, tag.Kind) || tag.ExtensionFields["unkown"] != "",
+				})
+			}
+		}
+	}
+
+	for i, fileName := range fileNames {
+		importPath := packageImportPath(modulePath, fileName)
+		for _, tag := range fileTags[i] {
+			occurrenceRange, ok := nameRange(tag)
+			if tag.ExtensionFields["roles"] != "ref" || !ok {
+				continue
+			}
+
+			if symbol := resolveReference(definitions[tag.Name], tag, importPath); symbol != "" {
+				documents[i].Occurrences = append(documents[i].Occurrences, scip.Occurrence{Range: occurrenceRange, Symbol: symbol})
+			}
+		}
+	}
+
+	return documents, nil
+}
+
+// packageImportPath returns the import path of the package of the file, whose
+// name is relative to the root of the module.
+func packageImportPath(modulePath, fileName string) string {
+	return path.Join(modulePath, filepath.ToSlash(filepath.Dir(fileName)))
+}
+
+// scipDescriptors returns the descriptors of the symbol of a definition, nil
+// for the names of imported packages, which are local to the file.
+func scipDescriptors(tag common.TagEntry, importPath string) []scip.Descriptor {
+	namespace := scip.Descriptor{Name: importPath, Suffix: "/"}
+
+	switch {
+	case tag.Kind == "p":
+		return []scip.Descriptor{namespace}
+	case tag.Kind == "P":
+		return nil
+	case tag.Kind == "m":
+		return []scip.Descriptor{namespace, {Name: scopeName(tag.ExtensionFields["struct"]), Suffix: "#"}, {Name: tag.Name, Suffix: "."}}
+	case tag.Kind == "n":
+		return []scip.Descriptor{namespace, {Name: scopeName(tag.ExtensionFields["interface"]), Suffix: "#"}, {Name: tag.Name, Suffix: "()."}}
+	case slices.Contains(FunctionKinds, tag.Kind):
+		if receiver, ok := tag.ExtensionFields["unkown"]; ok {
+			return []scip.Descriptor{namespace, {Name: scopeName(common.ReceiverTypeName(receiver)), Suffix: "#"}, {Name: tag.Name, Suffix: "()."}}
+		}
+
+		return []scip.Descriptor{namespace, {Name: tag.Name, Suffix: "()."}}
+	case slices.Contains([]string{"s", "i", "t", "a"}, tag.Kind):
+		return []scip.Descriptor{namespace, {Name: tag.Name, Suffix: "#"}}
+	}
+
+	return []scip.Descriptor{namespace, {Name: tag.Name, Suffix: "."}}
+}
+
+// scopeName returns the name of the type in a scope like 'main.Server'.
+func scopeName(scope string) string {
+	_, name, _ := strings.Cut(scope, ".")
+	return name
+}
+
+func scipSymbolInformation(tag common.TagEntry, symbol string, fullDoc bool) scip.SymbolInformation {
+	information := scip.SymbolInformation{Symbol: symbol, DisplayName: tag.Name}
+
+	doc := tag.ExtensionFields["doc"]
+	if fullDoc && tag.Doc != "" {
+		doc = tag.Doc
+	}
+
+	if doc != "" {
+		information.Documentation = []string{doc}
+	}
+
+	return information
+}
+
+// referenceKinds are the kinds of the definitions the kinds of reference tags
+// can refer to.
+var referenceKinds = map[string][]string{
+	KindCallReference:       append([]string{"n"}, FunctionKinds...),
+	kindTypeReference:       {"s", "i", "t", "a"},
+	kindMemberReference:     append([]string{"m", "n", "v", "c"}, FunctionKinds...),
+	kindIdentifierReference: append([]string{"v", "c"}, FunctionKinds...),
+}
+
+// resolveReference returns the symbol of the only definition of a kind the
+// reference can refer to, or an empty string. Without type information the
+// candidates are narrowed down by the syntax: names selected from an
+// imported package, like 'b.Start', refer to package level definitions of
+// that package, other selected names, like 's.Start', to members, and names
+// which are not selected to package level definitions of the package of the
+// reference. Uses of local names, and of the import names themselves, do not
+// refer to definitions with a symbol.
+func resolveReference(candidates []scipDefinition, reference common.TagEntry, importPath string) string {
+	if reference.ExtensionFields["local"] != "" {
+		return ""
+	}
+
+	_, isSelected := reference.ExtensionFields["qualifier"]
+	referencedImport, isImport := reference.ExtensionFields["import"]
+	if isImport && !isSelected {
+		return ""
+	}
+
+	candidates = slices.DeleteFunc(slices.Clone(candidates), func(candidate scipDefinition) bool {
+		if !slices.Contains(referenceKinds[reference.Kind], candidate.kind) {
+			return true
+		}
+
+		switch {
+		case isImport:
+			return candidate.member || candidate.importPath != referencedImport
+		case isSelected:
+			return !candidate.member
+		default:
+			return candidate.member || candidate.importPath != importPath
+		}
+	})
+
+	if len(candidates) != 1 {
+		return ""
+	}
+
+	return candidates[0].symbol
+}
+
+// nameRange returns the range of the name of the tag, as the 0 based line,
+// start column and end column, and false when the name has no column, like
+// the names regex definitions put together.
+func nameRange(tag common.TagEntry) ([3]int32, bool) {
+	line, err := strconv.Atoi(tag.ExtensionFields["line"])
+	if err != nil || line < 1 || tag.Column < 1 {
+		return [3]int32{}, false
+	}
+
+	column := int32(tag.Column - 1)
+	return [3]int32{int32(line - 1), column, column + int32(len(tag.Name))}, true
+}
@@ -0,0 +1,88 @@
+package golang
+
+import (
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+// keys of the struct tags that get recorded as '<key>tag' fields on the tags of
+// struct fields, e.g. `json:"user_id,omitempty"` becomes 'jsontag:user_id'
+var structTagKeys = []string{"json", "yaml", "db"}
+
+// structTagNames returns the names given to a struct field by its struct tag,
+// keyed by the struct tag key. Names that are empty or '-' are skipped.
+func structTagNames(rawTag string) map[string]string {
+	unquotedTag, err := strconv.Unquote(rawTag)
+	if err != nil {
+		return nil
+	}
+
+	names := map[string]string{}
+	for _, key := range structTagKeys {
+		value, ok := reflect.StructTag(unquotedTag).Lookup(key)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := strings.Cut(value, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		names[key] = name
+	}
+
+	return names
+}
+
+// setStructTagFields adds the struct tag names to the tags of the struct
+// fields and, with the 'struct-tag-aliases' option, returns additional tags
+// named after the struct tag names which point to the struct fields.
+func (p *Processor) setStructTagFields(fieldTags []common.TagEntry, rawTag string) []common.TagEntry {
+	names := structTagNames(rawTag)
+	if len(names) == 0 {
+		return nil
+	}
+
+	var aliasTags []common.TagEntry
+	for _, fieldTag := range fieldTags {
+		for key, name := range names {
+			fieldTag.ExtensionFields[key+"tag"] = name
+		}
+
+		if !p.Options.StructTagAliases {
+			continue
+		}
+
+		for _, key := range structTagKeys {
+			name, ok := names[key]
+			if !ok || name == fieldTag.Name || containsTagNamed(aliasTags, name) {
+				continue
+			}
+
+			aliasTag := fieldTag
+			aliasTag.Name = name
+			// the struct tag name is not the name at the column of the field
+			aliasTag.Column = 0
+			aliasTag.ExtensionFields = maps.Clone(fieldTag.ExtensionFields)
+			aliasTag.ExtensionFields["aliasof"] = fieldTag.Name
+			aliasTags = append(aliasTags, aliasTag)
+		}
+	}
+
+	return aliasTags
+}
+
+func containsTagNamed(tags []common.TagEntry, name string) bool {
+	for _, tag := range tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
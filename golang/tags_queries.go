@@ -0,0 +1,53 @@
+package golang
+
+import (
+	"strings"
+	"sync"
+
+	common "github.com/jha-naman/tree-tags/common"
+	tagquery "github.com/jha-naman/tree-tags/tagquery"
+)
+
+// the queries of the 'tags-query' option, compiled once for all files
+var tagsQueries sync.Map
+
+func cachedTagsQuery(source string) (*tagquery.Query, error) {
+	if query, ok := tagsQueries.Load(source); ok {
+		return query.(*tagquery.Query), nil
+	}
+
+	query, err := NewTagsQuery([]byte(source))
+	if err != nil {
+		return nil, err
+	}
+
+	tagsQueries.Store(source, query)
+
+	return query, nil
+}
+
+// extractQueryTags adds the tags for the definitions found by a query of the
+// 'tags-query' option, skipping the names already tagged. Definitions whose
+// kind is named like one of the go kinds get its letter, other kind names are
+// used as they are.
+func (p *Processor) extractQueryTags(definitions []tagquery.Definition) {
+	for _, definition := range definitions {
+		if p.taggedNames[definition.Name.StartByte()] {
+			continue
+		}
+		p.taggedNames[definition.Name.StartByte()] = true
+
+		tags := p.definitionTags([]tagquery.Definition{definition}, common.KindLetter(Kinds, definition.Kind))
+		if p.packageName != "" {
+			tags[0].ExtensionFields["package"] = p.packageName
+		}
+
+		if definition.Doc != nil {
+			p.setDoc(tags, strings.TrimSpace(strings.Join(p.commentLines(definition.Doc), "\n")))
+		} else {
+			p.setDocFields(tags, definition.Node)
+		}
+
+		p.Tags = append(p.Tags, tags...)
+	}
+}
@@ -1,10 +1,12 @@
 package golang
 
 import (
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/jha-naman/tree-tags/common"
+	"github.com/jha-naman/tree-tags/scip"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -16,7 +18,7 @@ func TestPackageDeclaration(t *testing.T) {
 			FileName:        "",
 			Address:         "/^package treetags$/;\"",
 			Kind:            "p",
-			ExtensionFields: nil,
+			ExtensionFields: map[string]string{"line": "1", "end": "1"},
 		},
 	}
 
@@ -31,7 +33,7 @@ func TestImportDeclaration(t *testing.T) {
 		{
 			input: `import assert "github.com/stretchr/testify/assert"`,
 			expectedTags: []common.TagEntry{
-				{Name: "assert", FileName: "", Address: `/^import assert "github.com\/stretchr\/testify\/assert"$/;"`, Kind: "P", ExtensionFields: map[string]string{"package": "github.com/stretchr/testify/assert"}},
+				{Name: "assert", FileName: "", Address: `/^import assert "github.com\/stretchr\/testify\/assert"$/;"`, Kind: "P", ExtensionFields: map[string]string{"line": "1", "end": "1", "package": "github.com/stretchr/testify/assert"}},
 			},
 		},
 		{
@@ -48,7 +50,7 @@ func TestImportDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         "/^\t\t\t\tassert \"github.com\\/stretchr\\/testify\\/assert\"$/;\"",
 					Kind:            "P",
-					ExtensionFields: map[string]string{"package": "github.com/stretchr/testify/assert"},
+					ExtensionFields: map[string]string{"line": "5", "end": "5", "package": "github.com/stretchr/testify/assert"},
 				},
 			},
 		},
@@ -72,14 +74,14 @@ func TestFunctionDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         `/^package main; func main() {}$/;"`,
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
 				},
 				{
 					Name:            "main",
 					FileName:        "",
 					Address:         "/^package main; func main() {}$/;\"",
 					Kind:            "f",
-					ExtensionFields: map[string]string{"package": "main"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "private", "package": "main", "signature": "()"},
 				},
 			},
 		},
@@ -91,14 +93,14 @@ func TestFunctionDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         `/^package main; func foo(bar, baz string, arr []string) (error, map[string]string) {}$/;"`,
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
 				},
 				{
 					Name:            "foo",
 					FileName:        "",
 					Address:         `/^package main; func foo(bar, baz string, arr []string) (error, map[string]string) {}$/;"`,
 					Kind:            "f",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "(error, map[string]string)"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "private", "package": "main", "typeref:typename": "(error, map[string]string)", "signature": "(bar, baz string, arr []string)"},
 				},
 			},
 		},
@@ -123,7 +125,7 @@ func TestVarDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         `/^package main; var x, y int$/;"`,
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
 				},
 
 				{
@@ -131,14 +133,14 @@ func TestVarDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         `/^package main; var x, y int$/;"`,
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "int"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "private", "package": "main", "typeref:typename": "int"},
 				},
 				{
 					Name:            "y",
 					FileName:        "",
 					Address:         `/^package main; var x, y int$/;"`,
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "int"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "private", "package": "main", "typeref:typename": "int"},
 				},
 			},
 		},
@@ -158,41 +160,41 @@ var (
 					FileName:        "",
 					Address:         "/^package main$/;\"",
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "2", "end": "2"},
 				},
 				{
 					Name:            "a",
 					FileName:        "",
 					Address:         "/^\ta, b int$/;\"",
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "int"},
+					ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "private", "package": "main", "typeref:typename": "int"},
 				},
 				{Name: "b",
 					FileName:        "",
 					Address:         "/^\ta, b int$/;\"",
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "int"},
+					ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "private", "package": "main", "typeref:typename": "int"},
 				},
 				{
 					Name:            "x",
 					FileName:        "",
 					Address:         "/^\tx map[string]string$/;\"",
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "map[string]string"},
+					ExtensionFields: map[string]string{"line": "5", "end": "5", "access": "private", "package": "main", "typeref:typename": "map[string]string"},
 				},
 				{
 					Name:            "i",
 					FileName:        "",
 					Address:         "/^\ti interface{}$/;\"",
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "interface{}"},
+					ExtensionFields: map[string]string{"line": "6", "end": "6", "access": "private", "package": "main", "typeref:typename": "interface{}"},
 				},
 				{
 					Name:            "z",
 					FileName:        "",
 					Address:         "/^\tz = \"zed\"$/;\"",
 					Kind:            "v",
-					ExtensionFields: map[string]string{"package": "main"},
+					ExtensionFields: map[string]string{"line": "7", "end": "7", "access": "private", "package": "main"},
 				},
 			},
 		},
@@ -216,7 +218,7 @@ func TestConstDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         `/^package main; const foo = "foo"$/;"`,
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
 				},
 
 				{
@@ -224,7 +226,7 @@ func TestConstDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         `/^package main; const foo = "foo"$/;"`,
 					Kind:            "c",
-					ExtensionFields: map[string]string{"package": "main"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "private", "package": "main"},
 				},
 			},
 		},
@@ -242,21 +244,21 @@ const (
 					FileName:        "",
 					Address:         `/^package main$/;"`,
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "2", "end": "2"},
 				},
 				{
 					Name:            "foo",
 					FileName:        "",
 					Address:         "/^\tfoo = \"foo\"$/;\"",
 					Kind:            "c",
-					ExtensionFields: map[string]string{"package": "main"},
+					ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "private", "package": "main"},
 				},
 				{
 					Name:            "bar",
 					FileName:        "",
 					Address:         "/^\tbar = 1$/;\"",
 					Kind:            "c",
-					ExtensionFields: map[string]string{"package": "main"},
+					ExtensionFields: map[string]string{"line": "5", "end": "5", "access": "private", "package": "main"},
 				},
 			},
 		},
@@ -281,21 +283,21 @@ func TestTypeDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         "/^package main; type Alias int; type AnotherOne Alias$/;\"",
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
 				},
 				{
 					Name:            "Alias",
 					FileName:        "",
 					Address:         "/^package main; type Alias int; type AnotherOne Alias$/;\"",
 					Kind:            "t",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "int"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "public", "package": "main", "typeref:typename": "int"},
 				},
 				{
 					Name:            "AnotherOne",
 					FileName:        "",
 					Address:         "/^package main; type Alias int; type AnotherOne Alias$/;\"",
 					Kind:            "t",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "Alias"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "public", "package": "main", "typeref:typename": "Alias"},
 				},
 			},
 		},
@@ -307,14 +309,14 @@ func TestTypeDeclaration(t *testing.T) {
 					FileName:        "",
 					Address:         "/^package main; type Alias = map[string]string$/;\"",
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
 				},
 				{
 					Name:            "Alias",
 					FileName:        "",
 					Address:         "/^package main; type Alias = map[string]string$/;\"",
 					Kind:            "a",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "map[string]string"},
+					ExtensionFields: map[string]string{"line": "1", "end": "1", "access": "public", "package": "main", "typeref:typename": "map[string]string"},
 				},
 			},
 		},
@@ -344,28 +346,28 @@ func (f *foo) Bar(baz string) map[string]string { return nil }
 					FileName:        "",
 					Address:         "/^package main$/;\"",
 					Kind:            "p",
-					ExtensionFields: nil,
+					ExtensionFields: map[string]string{"line": "2", "end": "2"},
 				},
 				{
 					Name:            "foo",
 					FileName:        "",
 					Address:         "/^type foo int$/;\"",
 					Kind:            "t",
-					ExtensionFields: map[string]string{"package": "main", "typeref:typename": "int"},
+					ExtensionFields: map[string]string{"line": "3", "end": "3", "access": "private", "package": "main", "typeref:typename": "int"},
 				},
 				{
 					Name:            "String",
 					FileName:        "",
 					Address:         "/^func (f foo) String() {}$/;\"",
 					Kind:            "f",
-					ExtensionFields: map[string]string{"unkown": "main.foo"},
+					ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "private", "unkown": "main.foo", "signature": "()"},
 				},
 				{
 					Name:            "Bar",
 					FileName:        "",
 					Address:         "/^func (f *foo) Bar(baz string) map[string]string { return nil }$/;\"",
 					Kind:            "f",
-					ExtensionFields: map[string]string{"unkown": "main.*foo", "typeref:typename": "map[string]string"},
+					ExtensionFields: map[string]string{"line": "5", "end": "5", "access": "private", "unkown": "main.*foo", "typeref:typename": "map[string]string", "signature": "(baz string)"},
 				},
 			},
 		},
@@ -376,12 +378,671 @@ func (f *foo) Bar(baz string) map[string]string { return nil }
 	}
 }
 
+func TestGeneratedFile(t *testing.T) {
+	tests := []struct {
+		input        string
+		expectedTags []common.TagEntry
+	}{
+		{
+			input: `// Code generated by stringer; DO NOT EDIT.
+
+package main`,
+			expectedTags: []common.TagEntry{
+				{
+					Name:            "main",
+					FileName:        "",
+					Address:         "/^package main$/;\"",
+					Kind:            "p",
+					ExtensionFields: map[string]string{"line": "3", "end": "3", "generated": "yes"},
+				},
+			},
+		},
+		{
+			input: `// Package main does things.
+// Code generated by hand; DO NOT EDIT.
+package main`,
+			expectedTags: []common.TagEntry{
+				{
+					Name:            "main",
+					FileName:        "",
+					Address:         "/^package main$/;\"",
+					Kind:            "p",
+					Doc:             "Package main does things.",
+					ExtensionFields: map[string]string{"line": "3", "end": "3", "generated": "yes", "doc": "Package main does things."},
+				},
+			},
+		},
+		{
+			input: `package main
+// Code generated by stringer; DO NOT EDIT.
+`,
+			expectedTags: []common.TagEntry{
+				{
+					Name:            "main",
+					FileName:        "",
+					Address:         "/^package main$/;\"",
+					Kind:            "p",
+					ExtensionFields: map[string]string{"line": "1", "end": "1"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedTags, extractTagsFromString(test.input))
+	}
+}
+
+func TestDocComments(t *testing.T) {
+	input := `package main
+
+// Foo does foo. It does nothing else.
+//
+// Deprecated: use Bar instead.
+func Foo() {}
+
+type (
+	// Alias is an int.
+	Alias int
+)
+
+// T holds things.
+type T struct {
+	/* X is the
+	   first field. */
+	X int
+	Y int // not a doc comment
+}
+
+var x = 1 // not a doc comment
+//go:generate stringer
+// y is documented.
+var y = 2
+`
+	expectedTags := []common.TagEntry{
+		{
+			Name:            "main",
+			FileName:        "",
+			Address:         "/^package main$/;\"",
+			Kind:            "p",
+			ExtensionFields: map[string]string{"line": "1", "end": "1"},
+		},
+		{
+			Name:            "Foo",
+			FileName:        "",
+			Address:         "/^func Foo() {}$/;\"",
+			Kind:            "f",
+			ExtensionFields: map[string]string{"line": "6", "end": "6", "access": "public", "package": "main", "doc": "Foo does foo.", "deprecated": "yes", "signature": "()"},
+			Doc:             "Foo does foo. It does nothing else.\n\nDeprecated: use Bar instead.",
+		},
+		{
+			Name:            "Alias",
+			FileName:        "",
+			Address:         "/^\tAlias int$/;\"",
+			Kind:            "t",
+			ExtensionFields: map[string]string{"line": "10", "end": "10", "access": "public", "package": "main", "typeref:typename": "int", "doc": "Alias is an int."},
+			Doc:             "Alias is an int.",
+		},
+		{
+			Name:            "T",
+			FileName:        "",
+			Address:         "/^type T struct {$/;\"",
+			Kind:            "s",
+			ExtensionFields: map[string]string{"line": "14", "end": "19", "access": "public", "package": "main", "doc": "T holds things."},
+			Doc:             "T holds things.",
+		},
+		{
+			Name:            "X",
+			FileName:        "",
+			Address:         "/^\tX int$/;\"",
+			Kind:            "m",
+			ExtensionFields: map[string]string{"line": "17", "end": "17", "access": "public", "struct": "main.T", "typeref:typename": "int", "doc": "X is the first field."},
+			Doc:             "X is the\n\t   first field.",
+		},
+		{
+			Name:            "Y",
+			FileName:        "",
+			Address:         "/^\tY int \\/\\/ not a doc comment$/;\"",
+			Kind:            "m",
+			ExtensionFields: map[string]string{"line": "18", "end": "18", "access": "public", "struct": "main.T", "typeref:typename": "int"},
+		},
+		{
+			Name:            "x",
+			FileName:        "",
+			Address:         "/^var x = 1 \\/\\/ not a doc comment$/;\"",
+			Kind:            "v",
+			ExtensionFields: map[string]string{"line": "21", "end": "21", "access": "private", "package": "main"},
+		},
+		{
+			Name:            "y",
+			FileName:        "",
+			Address:         "/^var y = 2$/;\"",
+			Kind:            "v",
+			ExtensionFields: map[string]string{"line": "24", "end": "24", "access": "private", "package": "main", "doc": "y is documented."},
+			Doc:             "y is documented.",
+		},
+	}
+
+	assert.Equal(t, expectedTags, extractTagsFromString(input))
+}
+
+func TestDocCommentGeneratedHeader(t *testing.T) {
+	input := `// Code generated by stringer; DO NOT EDIT.
+// Package main does things.
+package main
+
+// Code generated by hand; DO NOT EDIT.
+func Foo() {}
+`
+	expectedTags := []common.TagEntry{
+		{
+			Name:            "main",
+			FileName:        "",
+			Address:         "/^package main$/;\"",
+			Kind:            "p",
+			ExtensionFields: map[string]string{"line": "3", "end": "3", "generated": "yes", "doc": "Package main does things."},
+			Doc:             "Package main does things.",
+		},
+		{
+			Name:            "Foo",
+			FileName:        "",
+			Address:         "/^func Foo() {}$/;\"",
+			Kind:            "f",
+			ExtensionFields: map[string]string{"line": "6", "end": "6", "access": "public", "package": "main", "generated": "yes", "signature": "()"},
+		},
+	}
+
+	assert.Equal(t, expectedTags, extractTagsFromString(input))
+}
+
+func TestStructTags(t *testing.T) {
+	input := "package main\ntype User struct {\n\tUserID int `json:\"user_id,omitempty\" db:\"user_id\"`\n\tName string `json:\"-\" yaml:\"Name\"`\n}"
+	fieldTags := []common.TagEntry{
+		{
+			Name:            "UserID",
+			FileName:        "",
+			Address:         "/^\tUserID int `json:\"user_id,omitempty\" db:\"user_id\"`$/;\"",
+			Kind:            "m",
+			ExtensionFields: map[string]string{"line": "3", "end": "3", "access": "public", "struct": "main.User", "typeref:typename": "int", "jsontag": "user_id", "dbtag": "user_id"},
+		},
+		{
+			Name:            "Name",
+			FileName:        "",
+			Address:         "/^\tName string `json:\"-\" yaml:\"Name\"`$/;\"",
+			Kind:            "m",
+			ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "public", "struct": "main.User", "typeref:typename": "string", "yamltag": "Name"},
+		},
+	}
+
+	tags := extractTagsFromString(input)
+	assert.Equal(t, fieldTags, tags[2:])
+
+	tags = extractTagsFromStringWithOptions(input, common.Options{StructTagAliases: true})
+	assert.Equal(t, []common.TagEntry{
+		fieldTags[0],
+		{
+			Name:            "user_id",
+			FileName:        "",
+			Address:         fieldTags[0].Address,
+			Kind:            "m",
+			ExtensionFields: map[string]string{"line": "3", "end": "3", "access": "public", "struct": "main.User", "typeref:typename": "int", "jsontag": "user_id", "dbtag": "user_id", "aliasof": "UserID"},
+		},
+		fieldTags[1],
+	}, tags[2:])
+}
+
+func TestAccessFields(t *testing.T) {
+	input := `package foo
+import tt "testing"
+type bar struct{ Pub int }
+func (b bar) Exported() {}
+func (b *bar) Other() {}
+type Baz[T any] struct{ Pub T }
+func (b *Baz[T]) Exported() {}
+type reader interface{ Read() }`
+
+	access := map[string]string{}
+	for _, tag := range extractTagsFromString(input) {
+		scope := tag.ExtensionFields["struct"] + tag.ExtensionFields["interface"] + tag.ExtensionFields["unkown"]
+		access[tag.Kind+" "+scope+" "+tag.Name] = tag.ExtensionFields["access"]
+	}
+
+	// the members of unexported types are not exported, and packages and
+	// import names get no access field
+	assert.Equal(t, map[string]string{
+		"p  foo":                 "",
+		"P  tt":                  "",
+		"s  bar":                 "private",
+		"m foo.bar Pub":          "private",
+		"f foo.bar Exported":     "private",
+		"f foo.*bar Other":       "private",
+		"s  Baz":                 "public",
+		"m foo.Baz Pub":          "public",
+		"f foo.*Baz[T] Exported": "public",
+		"i  reader":              "private",
+		"n foo.reader Read":      "private",
+	}, access)
+}
+
+func TestTestFunctions(t *testing.T) {
+	input := `package main
+func TestFoo(t *testing.T) {}
+func TestT_Method(t *testing.T) {}
+func BenchmarkFoo(b *testing.B) {}
+func ExampleFoo() {}
+func Example_suffix() {}
+func FuzzFoo(f *testing.F) {}
+func Testify(t *testing.T) {}
+func TestBar(t *testing.B) {}
+`
+	expectedKinds := map[string]string{
+		"main":           "p",
+		"TestFoo":        "T",
+		"TestT_Method":   "T",
+		"BenchmarkFoo":   "B",
+		"ExampleFoo":     "E",
+		"Example_suffix": "E",
+		"FuzzFoo":        "F",
+		"Testify":        "f",
+		"TestBar":        "f",
+	}
+	expectedTargets := map[string]string{
+		"TestFoo":      "Foo",
+		"TestT_Method": "T.Method",
+		"BenchmarkFoo": "Foo",
+		"ExampleFoo":   "Foo",
+		"FuzzFoo":      "Foo",
+	}
+
+	p := processorFromString(input)
+	p.FileName = "main_test.go"
+
+	tags := p.GetTags()
+	assert.Len(t, tags, len(expectedKinds))
+	for _, tag := range tags {
+		assert.Equal(t, expectedKinds[tag.Name], tag.Kind, tag.Name)
+		assert.Equal(t, expectedTargets[tag.Name], tag.ExtensionFields["testof"], tag.Name)
+	}
+
+	p = processorFromString(input)
+	p.FileName = "main.go"
+
+	for _, tag := range p.GetTags() {
+		if tag.Kind != "p" {
+			assert.Equal(t, "f", tag.Kind, tag.Name)
+		}
+	}
+
+	// the parameter types are named by the name 'testing' is imported with
+	p = processorFromString(`package main
+import tt "testing"
+func TestAliased(t *tt.T) {}
+func TestNotAliased(t *testing.T) {}
+`)
+	p.FileName = "main_test.go"
+
+	kinds := map[string]string{}
+	for _, tag := range p.GetTags() {
+		kinds[tag.Name] = tag.Kind
+	}
+	assert.Equal(t, map[string]string{"main": "p", "tt": "P", "TestAliased": "T", "TestNotAliased": "f"}, kinds)
+}
+
+func TestConstEnums(t *testing.T) {
+	input := `package main
+type Color int
+const (
+	Red Color = iota
+	Green
+	_
+	Blue, Black = 1 << iota, -(iota + 1)
+	White
+	Name = "name"
+	Other
+)
+`
+	expectedFields := map[string]map[string]string{
+		"Red":   {"typeref:typename": "Color", "enum": "Color", "value": "0"},
+		"Green": {"typeref:typename": "Color", "enum": "Color", "value": "1"},
+		"_":     {"typeref:typename": "Color", "enum": "Color", "value": "2"},
+		"Blue":  {"value": "8"},
+		"Black": {"value": "-4"},
+		"White": {"value": "16"},
+		"Name":  {},
+		"Other": {},
+	}
+
+	tags := extractTagsFromString(input)
+	assert.Len(t, tags, len(expectedFields)+2)
+	for _, tag := range tags[2:] {
+		fields := map[string]string{}
+		for _, key := range []string{"typeref:typename", "enum", "value"} {
+			if value, ok := tag.ExtensionFields[key]; ok {
+				fields[key] = value
+			}
+		}
+
+		assert.Equal(t, expectedFields[tag.Name], fields, tag.Name)
+	}
+}
+
 func extractTagsFromString(codeStr string) []common.TagEntry {
+	return extractTagsFromStringWithOptions(codeStr, common.Options{})
+}
+
+func extractTagsFromStringWithOptions(codeStr string, options common.Options) []common.TagEntry {
+	p := processorFromString(codeStr)
+	p.Options = options
+	return p.GetTags()
+}
+
+func processorFromString(codeStr string) Processor {
 	var codeBytes [][]byte
 	for _, line := range strings.Split(codeStr, "\n") {
 		codeBytes = append(codeBytes, []byte(line))
 	}
 
-	p := Processor{FileBytes: codeBytes}
-	return p.GetTags()
+	return Processor{FileBytes: codeBytes}
+}
+
+func TestNewProcessor(t *testing.T) {
+	input := "package main\r\n\r\nfunc main() {}\r\n"
+
+	p, err := NewProcessor("cmd/main.go", strings.NewReader(input), common.Options{})
+	assert.NoError(t, err)
+
+	expectedTags := []common.TagEntry{
+		{Name: "main", FileName: "cmd/main.go", Address: `/^package main$/;"`, Kind: "p", ExtensionFields: map[string]string{"line": "1", "end": "1"}},
+		{Name: "main", FileName: "cmd/main.go", Address: `/^func main() {}$/;"`, Kind: "f", ExtensionFields: map[string]string{"line": "3", "end": "3", "access": "private", "package": "main", "signature": "()"}},
+	}
+
+	assert.Equal(t, expectedTags, p.GetTags())
+}
+
+func TestPatternLengthLimit(t *testing.T) {
+	tests := []struct {
+		limit           int
+		input           string
+		expectedAddress string
+	}{
+		{limit: 0, input: `package main; var s = "a\\/$"`, expectedAddress: `/^package main; var s = "a\\\\\/\$"$/;"`},
+		{limit: 20, input: `package main; var s = "a\\/$"`, expectedAddress: `/^package main; var s /;"`},
+		{limit: 19, input: `package main; var s = "a\\/$"`, expectedAddress: `/^package main; var s/;"`},
+		{limit: 50, input: `package main; var s = "a\\/$"`, expectedAddress: `/^package main; var s = "a\\\\\/\$"$/;"`},
+		{limit: 26, input: `package main; var s = "ab€"`, expectedAddress: `/^package main; var s = "ab/;"`},
+	}
+
+	for _, test := range tests {
+		tags := extractTagsFromStringWithOptions(test.input, common.Options{PatternLengthLimit: test.limit})
+		assert.Equal(t, test.expectedAddress, tags[len(tags)-1].Address, test.input)
+	}
+}
+
+func TestSourceLines(t *testing.T) {
+	input := "package main\nfunc Hello(aaaa, bbbb, cccc string) {}"
+
+	tags := extractTagsFromStringWithOptions(input, common.Options{PatternLengthLimit: 16, SourceLines: true})
+	assert.Equal(t, `/^func Hello(aaaa,/;"`, tags[1].Address)
+	assert.Equal(t, "func Hello(aaaa, bbbb, cccc string) {}", tags[1].SourceLine)
+
+	tags = extractTagsFromStringWithOptions(input, common.Options{PatternLengthLimit: 16})
+	assert.Equal(t, "", tags[1].SourceLine)
+}
+
+func TestInterfaceMethods(t *testing.T) {
+	input := "package main\ntype I interface {\n\tA() int\n\tB(x string)\n\tfmt.Stringer\n}"
+	expectedTags := []common.TagEntry{
+		{Name: "A", FileName: "", Address: `/^	A() int$/;"`, Kind: "n", ExtensionFields: map[string]string{"line": "3", "end": "3", "access": "public", "interface": "main.I", "typeref:typename": "int", "signature": "()"}},
+		{Name: "B", FileName: "", Address: `/^	B(x string)$/;"`, Kind: "n", ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "public", "interface": "main.I", "signature": "(x string)"}},
+	}
+
+	assert.Equal(t, expectedTags, extractTagsFromString(input)[2:])
+}
+
+func TestGenericTypes(t *testing.T) {
+	input := "package main\ntype G[T any] struct {\n\tX, Y T\n}\ntype L[T any] []T\nfunc (G[T]) M() {}"
+	expectedKinds := []string{"p", "s", "m", "m", "a", "f"}
+
+	var kinds []string
+	tags := extractTagsFromString(input)
+	for _, tag := range tags {
+		kinds = append(kinds, tag.Kind)
+	}
+
+	assert.Equal(t, expectedKinds, kinds)
+	assert.Equal(t, "[]T", tags[4].ExtensionFields["typeref:typename"])
+	assert.Equal(t, "main.G[T]", tags[5].ExtensionFields["unkown"])
+}
+
+func TestTagsQueries(t *testing.T) {
+	input := `package main
+var handlers = map[string]func(){
+	// handles the index page
+	"index": nil,
+}
+func main() {}`
+	query := `
+(literal_value
+  (comment) @doc
+  .
+  (keyed_element (literal_element (interpreted_string_literal) @name)) @definition.route)
+(function_declaration name: (identifier) @name) @definition.func`
+
+	tags := extractTagsFromStringWithOptions(input, common.Options{TagsQueries: []string{query}})
+	assert.Len(t, tags, 4)
+	assert.Equal(t, common.TagEntry{
+		Name:            `"index"`,
+		FileName:        "",
+		Address:         `/^	"index": nil,$/;"`,
+		Kind:            "route",
+		ExtensionFields: map[string]string{"line": "4", "end": "4", "access": "private", "package": "main", "doc": "handles the index page"},
+		Doc:             "handles the index page",
+	}, tags[3])
+}
+
+func TestReferences(t *testing.T) {
+	input := `package main
+func (s *Server) Start(port int) error {
+	addr, _ := fmt.Sprint(port), 0
+	for i := range s.items {
+		use(addr, i)
+	}
+	var x Config
+	return nil
+}`
+
+	var references []common.TagEntry
+	for _, tag := range extractTagsFromStringWithOptions(input, common.Options{References: true}) {
+		if tag.ExtensionFields["roles"] == "ref" {
+			references = append(references, tag)
+		}
+	}
+
+	var found [][3]string
+	for _, tag := range references {
+		found = append(found, [3]string{tag.Name, tag.Kind, tag.ExtensionFields["line"]})
+	}
+
+	assert.Equal(t, [][3]string{
+		{"fmt", "I", "3"},
+		{"Sprint", "C", "3"},
+		{"port", "I", "3"},
+		{"s", "I", "4"},
+		{"items", "M", "4"},
+		{"use", "C", "5"},
+		{"addr", "I", "5"},
+		{"i", "I", "5"},
+		{"Config", "Y", "7"},
+	}, found)
+	assert.Equal(t, common.TagEntry{
+		Name:            "Sprint",
+		FileName:        "",
+		Address:         "/^	addr, _ := fmt.Sprint(port), 0$/;\"",
+		Kind:            "C",
+		ExtensionFields: map[string]string{"line": "3", "func": "main.Server.Start", "access": "public", "roles": "ref", "qualifier": "fmt"},
+	}, references[1])
+
+	// the reference kinds have names of their own, unlike the kinds of the
+	// definitions they refer to
+	for _, tag := range references {
+		assert.NotEqual(t, tag.Kind, common.KindName(Kinds, tag.Kind), tag.Name)
+	}
+}
+
+func TestImplements(t *testing.T) {
+	input := `package main
+type Shape interface {
+	Area() float64
+	Name() string
+}
+type Empty interface{}
+type Square struct{}
+func (q *Square) Area() float64 { return 0 }
+func (q Square) Name() string { return "" }
+type Line struct{}
+func (Line) Name() string { return "" }
+type Named func()
+func (Named) Area() float64 { return 0 }
+func (Named) Name() string { return "" }
+type Solid interface {
+	Shape
+	Volume() float64
+}
+type Failing interface {
+	error
+	Name() string
+}
+type Remote interface {
+	io.Closer
+	Name() string
+}
+type Cube struct{}
+func (Cube) Area() float64 { return 0 }
+func (Cube) Name() string { return "" }
+func (Cube) Volume() float64 { return 0 }
+func (Cube) Error() string { return "" }`
+
+	tags := extractTagsFromString(input)
+	tags = append(tags, common.TagEntry{Name: "Line", Kind: "s", ExtensionFields: map[string]string{"package": "other", "implements": "main.Shape"}})
+	SetImplementsFields(tags)
+
+	implements := map[string]string{}
+	for _, tag := range tags {
+		if value, ok := tag.ExtensionFields["implements"]; ok {
+			implements[tag.ExtensionFields["package"]+"."+tag.Name] = value
+		}
+
+		if tag.Name == "Remote" {
+			assert.Equal(t, "io.Closer", tag.ExtensionFields["embeds"])
+		}
+	}
+
+	// the methods of 'Shape' are needed for 'Solid', and 'Remote' embeds an
+	// interface of another package, so it is left out
+	assert.Equal(t, map[string]string{
+		"main.Square": "main.Shape",
+		"main.Named":  "main.Shape",
+		"main.Cube":   "main.Failing,main.Shape,main.Solid",
+	}, implements)
+}
+
+func TestImplementsDirectories(t *testing.T) {
+	files := map[string]string{
+		"a/a.go":          "package main\ntype Namer interface{ Name() string }",
+		"b/b.go":          "package main\ntype T struct{}\nfunc (T) Name() string { return \"\" }",
+		"b/namer.go":      "package main\ntype Labeler interface{ Label() string }",
+		"lib/lib.go":      "package lib\ntype Namer interface{ Name() string }\ntype labeler interface{ Label() string }",
+		"lib/lib_test.go": "package lib_test\ntype Labeler interface{ Label() string }",
+		"c/c.go":          "package main\ntype T struct{}\nfunc (T) Label() string { return \"\" }",
+	}
+
+	var tags []common.TagEntry
+	for fileName, source := range files {
+		tags = append(tags, NewProcessorFromBytes(fileName, []byte(source), common.Options{}).GetTags()...)
+	}
+	SetImplementsFields(tags)
+
+	implements := map[string]string{}
+	for _, tag := range tags {
+		if value, ok := tag.ExtensionFields["implements"]; ok {
+			implements[tag.FileName] = value
+		}
+	}
+
+	// the 'main' packages and the external test package cannot be imported
+	// and 'lib.labeler' is not exported, so only 'lib.Namer' is implemented
+	// by a type of another directory
+	assert.Equal(t, map[string]string{"b/b.go": "lib.Namer"}, implements)
+}
+
+func TestSCIPDocuments(t *testing.T) {
+	wd, err := os.Getwd()
+	assert.NoError(t, err)
+	assert.NoError(t, os.Chdir(t.TempDir()))
+	defer os.Chdir(wd)
+
+	assert.NoError(t, os.Mkdir("server", 0o755))
+	assert.NoError(t, os.WriteFile("server/server.go", []byte(`package server
+
+type Server struct{}
+
+func (s *Server) Start() { s.Start() }
+`), 0o644))
+
+	documents, err := SCIPDocuments("example.com/m", "", []string{"server/server.go"}, common.Options{})
+	assert.NoError(t, err)
+	assert.Len(t, documents, 1)
+
+	prefix := "scip-go gomod example.com/m . `example.com/m/server`/"
+	assert.Equal(t, "server/server.go", documents[0].RelativePath)
+	assert.Equal(t, []scip.Occurrence{
+		{Range: [3]int32{0, 8, 14}, Symbol: prefix, SymbolRoles: scip.SymbolRoleDefinition},
+		{Range: [3]int32{2, 5, 11}, Symbol: prefix + "Server#", SymbolRoles: scip.SymbolRoleDefinition},
+		{Range: [3]int32{4, 17, 22}, Symbol: prefix + "Server#Start().", SymbolRoles: scip.SymbolRoleDefinition},
+		{Range: [3]int32{4, 29, 34}, Symbol: prefix + "Server#Start()."},
+	}, documents[0].Occurrences)
+
+	// selected names only refer to the package imported under the name of
+	// the selector, and local names do not refer to package level ones
+	assert.NoError(t, os.Mkdir("a", 0o755))
+	assert.NoError(t, os.WriteFile("a/a.go", []byte(`package a
+
+import "example.com/m/server"
+
+var count = 1
+
+func Start() {
+	server.Start()
+	count := 2
+	_ = count
+	Start()
+}
+`), 0o644))
+	assert.NoError(t, os.WriteFile("server/start.go", []byte(`package server
+
+func Start() {}
+`), 0o644))
+
+	documents, err = SCIPDocuments("example.com/m", "", []string{"a/a.go", "server/start.go"}, common.Options{})
+	assert.NoError(t, err)
+	assert.Len(t, documents, 2)
+
+	aPrefix := "scip-go gomod example.com/m . `example.com/m/a`/"
+	assert.Equal(t, []scip.Occurrence{
+		{Range: [3]int32{0, 8, 9}, Symbol: aPrefix, SymbolRoles: scip.SymbolRoleDefinition},
+		{Range: [3]int32{4, 4, 9}, Symbol: aPrefix + "count.", SymbolRoles: scip.SymbolRoleDefinition},
+		{Range: [3]int32{6, 5, 10}, Symbol: aPrefix + "Start().", SymbolRoles: scip.SymbolRoleDefinition},
+		{Range: [3]int32{7, 8, 13}, Symbol: prefix + "Start()."},
+		{Range: [3]int32{10, 1, 6}, Symbol: aPrefix + "Start()."},
+	}, documents[0].Occurrences)
+
+	// the range of a regex tag is the one of the group matching its name,
+	// not of the first place the name is in the line
+	assert.NoError(t, os.WriteFile("routes.go", []byte("package main\nvar usersHandler = 1 // ROUTE: users\n"), 0o644))
+	route, err := common.ParseRegexDefinition("go", `/ROUTE: (\w+)/\1/r,route/`)
+	assert.NoError(t, err)
+
+	documents, err = SCIPDocuments("example.com/m", "", []string{"routes.go"}, common.Options{RegexDefinitions: []common.RegexDefinition{route}})
+	assert.NoError(t, err)
+	assert.Contains(t, documents[0].Occurrences, scip.Occurrence{Range: [3]int32{1, 31, 36}, Symbol: "scip-go gomod example.com/m . `example.com/m`/users.", SymbolRoles: scip.SymbolRoleDefinition})
 }
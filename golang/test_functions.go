@@ -0,0 +1,109 @@
+package golang
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// kinds of the functions the go test tool runs from '_test.go' files
+const (
+	kindTest      = "T"
+	kindBenchmark = "B"
+	kindExample   = "E"
+	kindFuzz      = "F"
+)
+
+// testFunctionPrefixes are the prefixes of the names of test functions, with
+// the 'testing' package type of their parameter.
+var testFunctionPrefixes = []struct {
+	prefix, kind, parameterType string
+}{
+	{"Test", kindTest, "T"},
+	{"Benchmark", kindBenchmark, "B"},
+	{"Fuzz", kindFuzz, "F"},
+	{"Example", kindExample, ""},
+}
+
+// testFunctionKind returns the kind of test function, if any, for a function
+// declared in a '_test.go' file, along with the name of the function or
+// method it tests. 'TestFoo' tests 'Foo' and 'ExampleT_Method' is an example
+// of the method 'T.Method'. The testing name is the name the file imports the
+// 'testing' package with, like 'tt' for 'import tt "testing"'.
+func testFunctionKind(name, parameters, result, testingName string) (kind, target string) {
+	for _, testFunction := range testFunctionPrefixes {
+		if !isTestFunctionName(name, testFunction.prefix) || result != "" {
+			continue
+		}
+
+		parameterTypes := parameterTypes(parameters)
+		if testFunction.parameterType == "" && len(parameterTypes) != 0 {
+			continue
+		}
+
+		parameterType := "*" + testingName + "." + testFunction.parameterType
+		if testFunction.parameterType != "" && !slices.Equal(parameterTypes, []string{parameterType}) {
+			continue
+		}
+
+		return testFunction.kind, testTarget(strings.TrimPrefix(name, testFunction.prefix))
+	}
+
+	return "", ""
+}
+
+// isTestFunctionName follows the rule of the go test tool, the prefix must not
+// be followed by a lower case letter, so that 'Testify' is not a test.
+func isTestFunctionName(name, prefix string) bool {
+	if !strings.HasPrefix(name, prefix) {
+		return false
+	}
+
+	if len(name) == len(prefix) {
+		return true
+	}
+
+	r, _ := utf8.DecodeRuneInString(name[len(prefix):])
+	return !unicode.IsLower(r)
+}
+
+// parameterTypes returns the types of the parameters in a parameter list like
+// '(t *testing.T)'. A list like '(a, b int)' gives 'a' and 'int', which is
+// still good enough to tell test functions apart.
+func parameterTypes(parameters string) []string {
+	parameters = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(parameters, "("), ")"))
+	if parameters == "" {
+		return nil
+	}
+
+	var types []string
+	for _, parameter := range strings.Split(parameters, ",") {
+		fields := strings.Fields(parameter)
+		if len(fields) == 0 {
+			continue
+		}
+		types = append(types, fields[len(fields)-1])
+	}
+
+	return types
+}
+
+// testTarget returns the name of the function, type or method a test
+// function is for given the rest of its name after the prefix. A name like
+// 'Example_suffix' is not for any particular identifier.
+func testTarget(suffix string) string {
+	if suffix == "" || strings.HasPrefix(suffix, "_") {
+		return ""
+	}
+
+	target, method, found := strings.Cut(suffix, "_")
+	if found && method != "" {
+		if r, _ := utf8.DecodeRuneInString(method); unicode.IsUpper(r) {
+			method, _, _ = strings.Cut(method, "_")
+			return target + "." + method
+		}
+	}
+
+	return target
+}
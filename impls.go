@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+)
+
+// runImpls implements the 'impls' subcommand, printing the tags of the types
+// implementing an interface, from their 'implements' field in the tags file.
+func runImpls(args []string) {
+	flagSet := flag.NewFlagSet("impls", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: tree-tags impls [options] INTERFACE")
+		flagSet.PrintDefaults()
+	}
+
+	var tagFile string
+	var jsonOutput bool
+	flagSet.StringVar(&tagFile, "t", tagFileName, "tags file to search")
+	flagSet.BoolVar(&jsonOutput, "json", false, "print the tags as json lines instead of the lines of the tags file")
+
+	names := parseInterspersed(flagSet, subcommandArgs(flagSet, args))
+	if len(names) != 1 {
+		flagSet.Usage()
+		os.Exit(2)
+	}
+
+	interfaceName := names[0]
+	tags, err := readTagFile(tagFile, func(tag common.TagEntry) bool {
+		return slices.ContainsFunc(strings.Split(tag.ExtensionFields["implements"], ","), func(implemented string) bool {
+			return matchesInterfaceName(implemented, interfaceName)
+		})
+	})
+	if err != nil {
+		log.Fatal("error while reading tags:", err.Error())
+	}
+
+	writer := bufio.NewWriter(os.Stdout)
+	for _, tag := range tags {
+		line := tag.Bytes()
+		if jsonOutput {
+			if line, err = tag.JSONBytes(false, golang.Kinds); err != nil {
+				log.Fatal("error while writing tag:", err.Error())
+			}
+		}
+
+		if _, err = writer.Write(append(line, '\n')); err != nil {
+			log.Fatal("error while writing tag:", err.Error())
+		}
+	}
+
+	if err = writer.Flush(); err != nil {
+		log.Fatal("error while writing tag:", err.Error())
+	}
+
+	if len(tags) == 0 {
+		os.Exit(1)
+	}
+}
+
+// matchesInterfaceName reports whether the interface of an 'implements' field,
+// like 'io.Reader', is the one given, with or without the package name.
+func matchesInterfaceName(implemented, interfaceName string) bool {
+	if strings.Contains(interfaceName, ".") {
+		return implemented == interfaceName
+	}
+
+	return strings.HasSuffix(implemented, "."+interfaceName)
+}
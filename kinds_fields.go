@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"text/tabwriter"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+)
+
+// goKinds returns the kinds of the tags of go files, the ones of the go
+// extraction followed by the ones of the go regex definitions.
+func goKinds() []common.Kind {
+	kinds := slices.Clone(golang.Kinds)
+	for _, definition := range options.RegexDefinitions {
+		if definition.Language != "go" {
+			continue
+		}
+
+		if !slices.ContainsFunc(kinds, func(kind common.Kind) bool { return kind.Letter == definition.Kind.Letter }) {
+			kinds = append(kinds, definition.Kind)
+		}
+	}
+
+	return kinds
+}
+
+// initKindsAndFields sets the enabled kinds and fields from the 'kinds-go'
+// and 'fields' options.
+func initKindsAndFields(kindsSelection, fieldsSelection string) error {
+	kindLetters, kindNames := map[string]string{}, []string{}
+	for _, kind := range goKinds() {
+		kindLetters[kind.Letter] = kind.Name
+		kindNames = append(kindNames, kind.Name)
+	}
+
+	// like the fields, all kinds are enabled by default and relative
+	// selections like '-f' are applied to that
+	options.Kinds = map[string]bool{}
+	for _, name := range kindNames {
+		options.Kinds[name] = true
+	}
+
+	if err := common.ApplySelection(options.Kinds, kindsSelection, kindLetters, kindNames); err != nil {
+		return fmt.Errorf("invalid value %q for 'kinds-go' option: %w", kindsSelection, err)
+	}
+
+	fieldLetters, fieldNames := map[string]string{}, []string{}
+	for _, field := range golang.Fields {
+		if field.Letter != "" {
+			fieldLetters[field.Letter] = field.Name
+		}
+		fieldNames = append(fieldNames, field.Name)
+	}
+
+	options.Fields = common.DefaultFields(golang.Fields)
+	if fieldsSelection == "" {
+		return nil
+	}
+
+	if err := common.ApplySelection(options.Fields, fieldsSelection, fieldLetters, fieldNames); err != nil {
+		return fmt.Errorf("invalid value %q for 'fields' option: %w", fieldsSelection, err)
+	}
+
+	return nil
+}
+
+// filterKinds drops the tags of go files whose kinds are not enabled. Tags of
+// other kinds, like the ones of tags queries, are kept.
+func filterKinds(tags []common.TagEntry) []common.TagEntry {
+	kinds := goKinds()
+
+	return slices.DeleteFunc(tags, func(tag common.TagEntry) bool {
+		name := common.KindName(kinds, tag.Kind)
+		known := slices.ContainsFunc(kinds, func(kind common.Kind) bool { return kind.Name == name })
+
+		return path.Ext(tag.FileName) == ".go" && known && !options.Kinds[name]
+	})
+}
+
+func writeKindList(writer io.Writer) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(tabWriter, "#LETTER\tNAME\tENABLED\tDESCRIPTION")
+	for _, kind := range goKinds() {
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", kind.Letter, kind.Name, yesNo(options.Kinds[kind.Name]), kind.Description)
+	}
+	tabWriter.Flush()
+}
+
+func writeFieldList(writer io.Writer) {
+	tabWriter := tabwriter.NewWriter(writer, 0, 8, 1, ' ', 0)
+	fmt.Fprintln(tabWriter, "#LETTER\tNAME\tENABLED\tDESCRIPTION")
+	for _, field := range golang.Fields {
+		letter := field.Letter
+		if letter == "" {
+			letter = "-"
+		}
+		fmt.Fprintf(tabWriter, "%s\t%s\t%s\t%s\n", letter, field.Name, yesNo(options.Fields[field.Name]), field.Description)
+	}
+	tabWriter.Flush()
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	common "github.com/jha-naman/tree-tags/common"
+	lsp "github.com/jha-naman/tree-tags/lsp"
+)
+
+// runLanguageServer implements the 'lsp' subcommand, serving the language
+// server protocol over stdin and stdout.
+func runLanguageServer(args []string) {
+	flagSet := flag.NewFlagSet("lsp", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: tree-tags lsp [options]")
+		flagSet.PrintDefaults()
+	}
+
+	serverOptions := common.Options{}
+	flagSet.BoolVar(&serverOptions.StructTagAliases, "struct-tag-aliases", false, "add symbols named after the json, yaml and db struct tag names of struct fields")
+	_ = flagSet.Parse(subcommandArgs(flagSet, args))
+
+	if err := lsp.NewServer(serverOptions).Serve(os.Stdin, os.Stdout); err != nil {
+		log.Fatal("error while serving the language server protocol:", err.Error())
+	}
+}
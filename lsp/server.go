@@ -0,0 +1,499 @@
+// Package lsp implements a language server answering symbol and definition
+// requests from the tags extracted from the workspace, for editors without
+// ctags support.
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+)
+
+type Server struct {
+	Options common.Options
+
+	rootPath string
+	// tags of the workspace by the name of their file, relative to the root
+	tags map[string][]common.TagEntry
+	// contents of the documents open in the editor
+	documents map[string][]byte
+	writer    io.Writer
+}
+
+func NewServer(options common.Options) *Server {
+	return &Server{
+		Options:   options,
+		tags:      map[string][]common.TagEntry{},
+		documents: map[string][]byte{},
+	}
+}
+
+// Serve reads json-rpc messages from the reader and writes the responses to
+// the writer until the client sends the 'exit' notification.
+func (s *Server) Serve(reader io.Reader, writer io.Writer) error {
+	s.writer = writer
+	messageReader := textproto.NewReader(bufio.NewReader(reader))
+
+	for {
+		body, err := readMessage(messageReader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var req request
+		if err = json.Unmarshal(body, &req); err != nil {
+			return fmt.Errorf("invalid message: %w", err)
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, respErr := s.handle(req)
+		if req.ID == nil {
+			continue
+		}
+
+		if err = s.respond(req.ID, result, respErr); err != nil {
+			return err
+		}
+	}
+}
+
+func readMessage(reader *textproto.Reader) ([]byte, error) {
+	header, err := reader.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	contentLength, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+
+	body := make([]byte, contentLength)
+	_, err = io.ReadFull(reader.R, body)
+	return body, err
+}
+
+func (s *Server) respond(id *json.RawMessage, result any, respErr *responseError) error {
+	resp := response{JSONRPC: "2.0", ID: id, Error: respErr}
+	if respErr == nil {
+		resultBytes, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = resultBytes
+	}
+
+	body, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(s.writer, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
+
+func (s *Server) handle(req request) (any, *responseError) {
+	switch req.Method {
+	case "initialize":
+		var params initializeParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.initialize(params)
+	case "initialized", "shutdown", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params didOpenTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.openDocument(params.TextDocument.URI, []byte(params.TextDocument.Text))
+		return nil, nil
+	case "textDocument/didChange":
+		var params didChangeTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if changeCount := len(params.ContentChanges); changeCount > 0 {
+			// the server only asks for full document syncs
+			s.openDocument(params.TextDocument.URI, []byte(params.ContentChanges[changeCount-1].Text))
+		}
+		return nil, nil
+	case "textDocument/didSave":
+		var params didSaveTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		if params.Text != nil {
+			s.openDocument(params.TextDocument.URI, []byte(*params.Text))
+		} else {
+			s.reindexFromDisk(params.TextDocument.URI)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params didCloseTextDocumentParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		delete(s.documents, s.fileName(params.TextDocument.URI))
+		s.reindexFromDisk(params.TextDocument.URI)
+		return nil, nil
+	case "workspace/symbol":
+		var params workspaceSymbolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.workspaceSymbols(params.Query), nil
+	case "textDocument/documentSymbol":
+		var params documentSymbolParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.documentSymbols(params.TextDocument.URI), nil
+	case "textDocument/definition":
+		var params textDocumentPositionParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, invalidParams(err)
+		}
+		return s.definition(params.TextDocument.URI, params.Position), nil
+	}
+
+	return nil, &responseError{Code: errorCodeMethodNotFound, Message: "method not supported: " + req.Method}
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: errorCodeInvalidParams, Message: err.Error()}
+}
+
+func (s *Server) initialize(params initializeParams) (any, *responseError) {
+	s.rootPath = params.RootPath
+	if params.RootURI != "" {
+		s.rootPath = uriToPath(params.RootURI)
+	}
+
+	if s.rootPath == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, &responseError{Code: errorCodeInternalError, Message: err.Error()}
+		}
+		s.rootPath = wd
+	}
+
+	if err := s.indexWorkspace(); err != nil {
+		return nil, &responseError{Code: errorCodeInternalError, Message: err.Error()}
+	}
+
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync": map[string]any{
+				"openClose": true,
+				// full document syncs
+				"change": 1,
+				"save":   map[string]any{"includeText": true},
+			},
+			"workspaceSymbolProvider": true,
+			"documentSymbolProvider":  true,
+			"definitionProvider":      true,
+		},
+		"serverInfo": map[string]any{"name": "tree-tags"},
+	}, nil
+}
+
+func (s *Server) indexWorkspace() error {
+	return filepath.WalkDir(s.rootPath, func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() || filepath.Ext(filePath) != ".go" {
+			return nil
+		}
+
+		// files that can't be read are left out of the index
+		if content, err := os.ReadFile(filePath); err == nil {
+			s.indexFile(s.relativePath(filePath), content)
+		}
+		return nil
+	})
+}
+
+func (s *Server) openDocument(uri string, content []byte) {
+	fileName := s.fileName(uri)
+	s.documents[fileName] = content
+	s.indexFile(fileName, content)
+}
+
+func (s *Server) reindexFromDisk(uri string) {
+	fileName := s.fileName(uri)
+
+	content, err := os.ReadFile(filepath.Join(s.rootPath, fileName))
+	if err != nil {
+		delete(s.tags, fileName)
+		return
+	}
+
+	s.indexFile(fileName, content)
+}
+
+func (s *Server) indexFile(fileName string, content []byte) {
+	if filepath.Ext(fileName) != ".go" {
+		return
+	}
+
+	s.tags[fileName] = golang.NewProcessorFromBytes(fileName, content, s.Options).GetTags()
+}
+
+func splitLines(content []byte) [][]byte {
+	lines := bytes.Split(content, []byte("\n"))
+	for i, line := range lines {
+		lines[i] = bytes.TrimSuffix(line, []byte("\r"))
+	}
+
+	return lines
+}
+
+func (s *Server) workspaceSymbols(query string) []symbolInformation {
+	symbols := []symbolInformation{}
+	query = strings.ToLower(query)
+
+	for _, fileTags := range s.tags {
+		for _, tag := range fileTags {
+			if strings.Contains(strings.ToLower(tag.Name), query) {
+				symbols = append(symbols, s.symbolInformation(tag))
+			}
+		}
+	}
+
+	sort.Slice(symbols, func(i, j int) bool {
+		if symbols[i].Name != symbols[j].Name {
+			return symbols[i].Name < symbols[j].Name
+		}
+		return symbols[i].Location.URI < symbols[j].Location.URI
+	})
+
+	return symbols
+}
+
+func (s *Server) documentSymbols(uri string) []symbolInformation {
+	symbols := []symbolInformation{}
+	for _, tag := range s.tags[s.fileName(uri)] {
+		symbols = append(symbols, s.symbolInformation(tag))
+	}
+
+	return symbols
+}
+
+// definition looks up the tags named after the identifier under the cursor,
+// the ones from the same file first.
+func (s *Server) definition(uri string, pos position) []location {
+	locations := []location{}
+
+	fileName := s.fileName(uri)
+	name := identifierAt(s.documentLines(fileName), pos)
+	if name == "" {
+		return locations
+	}
+
+	var sameFileLocations []location
+	for tagFileName, fileTags := range s.tags {
+		for _, tag := range fileTags {
+			if tag.Name != name {
+				continue
+			}
+
+			if tagFileName == fileName {
+				sameFileLocations = append(sameFileLocations, s.location(tag))
+			} else {
+				locations = append(locations, s.location(tag))
+			}
+		}
+	}
+
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].URI < locations[j].URI
+	})
+
+	return append(sameFileLocations, locations...)
+}
+
+func (s *Server) documentLines(fileName string) [][]byte {
+	if content, ok := s.documents[fileName]; ok {
+		return splitLines(content)
+	}
+
+	content, err := os.ReadFile(filepath.Join(s.rootPath, fileName))
+	if err != nil {
+		return nil
+	}
+
+	return splitLines(content)
+}
+
+// identifierAt returns the go identifier around the position, which counts
+// characters in utf-16 code units.
+func identifierAt(lines [][]byte, pos position) string {
+	if pos.Line < 0 || pos.Line >= len(lines) {
+		return ""
+	}
+
+	line := []rune(string(lines[pos.Line]))
+	index, units := 0, 0
+	for index < len(line) && units < pos.Character {
+		units += utf16RuneLength(line[index])
+		index++
+	}
+
+	isIdentifierRune := func(r rune) bool {
+		return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+
+	start, end := index, index
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+	for end < len(line) && isIdentifierRune(line[end]) {
+		end++
+	}
+
+	return string(line[start:end])
+}
+
+func (s *Server) symbolInformation(tag common.TagEntry) symbolInformation {
+	symbol := symbolInformation{
+		Name:     tag.Name,
+		Kind:     symbolKind(tag),
+		Location: s.location(tag),
+	}
+
+	for _, fieldName := range []string{"unkown", "struct", "interface", "package"} {
+		if container, ok := tag.ExtensionFields[fieldName]; ok {
+			symbol.ContainerName = container
+			break
+		}
+	}
+
+	return symbol
+}
+
+func symbolKind(tag common.TagEntry) int {
+	switch tag.Kind {
+	case "p":
+		return symbolKindPackage
+	case "P":
+		return symbolKindModule
+	case "f":
+		if _, isMethod := tag.ExtensionFields["unkown"]; isMethod {
+			return symbolKindMethod
+		}
+		return symbolKindFunction
+	case "v":
+		return symbolKindVariable
+	case "c":
+		return symbolKindConstant
+	case "t", "a":
+		// the protocol has no kind for type aliases, they are shown like the
+		// other named types
+		return symbolKindClass
+	case "s":
+		return symbolKindStruct
+	case "i":
+		return symbolKindInterface
+	case "m":
+		return symbolKindField
+	case "n":
+		return symbolKindMethod
+	}
+
+	return symbolKindFunction
+}
+
+// location points to the tag name on the line of the tag. The column is found
+// by looking for the name in the line the tag pattern matches.
+func (s *Server) location(tag common.TagEntry) location {
+	line, _ := strconv.Atoi(tag.ExtensionFields["line"])
+	if line > 0 {
+		line--
+	}
+
+	lineText := common.PatternText(tag.Address)
+	column := strings.Index(lineText, tag.Name)
+	if column < 0 {
+		column = 0
+	}
+
+	start := utf16Length(lineText[:column])
+	return location{
+		URI: pathToURI(filepath.Join(s.rootPath, tag.FileName)),
+		Range: textRange{
+			Start: position{Line: line, Character: start},
+			End:   position{Line: line, Character: start + utf16Length(tag.Name)},
+		},
+	}
+}
+
+func utf16Length(text string) int {
+	length := 0
+	for len(text) > 0 {
+		r, size := utf8.DecodeRuneInString(text)
+		length += utf16RuneLength(r)
+		text = text[size:]
+	}
+
+	return length
+}
+
+func utf16RuneLength(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
+
+func (s *Server) fileName(uri string) string {
+	return s.relativePath(uriToPath(uri))
+}
+
+func (s *Server) relativePath(filePath string) string {
+	relativePath, err := filepath.Rel(s.rootPath, filePath)
+	if err != nil {
+		return filePath
+	}
+
+	return relativePath
+}
+
+func uriToPath(uri string) string {
+	parsedURI, err := url.Parse(uri)
+	if err != nil || parsedURI.Scheme != "file" {
+		return uri
+	}
+
+	return filepath.FromSlash(parsedURI.Path)
+}
+
+func pathToURI(filePath string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(filePath)}).String()
+}
@@ -0,0 +1,121 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	common "github.com/jha-naman/tree-tags/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer(t *testing.T) {
+	rootPath := t.TempDir()
+	mainFile := filepath.Join(rootPath, "main.go")
+	assert.NoError(t, os.WriteFile(mainFile, []byte("package main\n\nfunc main() {\n\tfoo()\n}\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(rootPath, "foo.go"), []byte("package main\n\nfunc foo() {}\n"), 0o644))
+
+	mainURI := pathToURI(mainFile)
+	fooURI := pathToURI(filepath.Join(rootPath, "foo.go"))
+
+	var input bytes.Buffer
+	writeMessage := func(id int, method string, params any) {
+		message := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+		if id != 0 {
+			message["id"] = id
+		}
+
+		body, err := json.Marshal(message)
+		assert.NoError(t, err)
+		fmt.Fprintf(&input, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	}
+
+	writeMessage(1, "initialize", map[string]any{"rootUri": pathToURI(rootPath)})
+	writeMessage(0, "initialized", map[string]any{})
+	writeMessage(2, "textDocument/definition", map[string]any{
+		"textDocument": map[string]any{"uri": mainURI},
+		"position":     map[string]any{"line": 3, "character": 2},
+	})
+	// the unsaved buffer moves foo down a line and adds bar
+	writeMessage(0, "textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": fooURI, "text": "package main\n\n\nfunc foo() {}\nfunc bar() {}\n"},
+	})
+	writeMessage(3, "textDocument/definition", map[string]any{
+		"textDocument": map[string]any{"uri": mainURI},
+		"position":     map[string]any{"line": 3, "character": 4},
+	})
+	writeMessage(4, "workspace/symbol", map[string]any{"query": "BA"})
+	writeMessage(5, "textDocument/documentSymbol", map[string]any{"textDocument": map[string]any{"uri": mainURI}})
+	writeMessage(6, "textDocument/hover", map[string]any{})
+	writeMessage(7, "shutdown", nil)
+	writeMessage(0, "exit", nil)
+
+	var output bytes.Buffer
+	assert.NoError(t, NewServer(common.Options{}).Serve(&input, &output))
+
+	responses := map[int]response{}
+	reader := textproto.NewReader(bufio.NewReader(&output))
+	for {
+		body, err := readMessage(reader)
+		if err != nil {
+			break
+		}
+
+		var resp response
+		assert.NoError(t, json.Unmarshal(body, &resp))
+
+		var id int
+		assert.NoError(t, json.Unmarshal(*resp.ID, &id))
+		responses[id] = resp
+	}
+
+	assert.Len(t, responses, 7)
+
+	fooLocation := func(line int) string {
+		return fmt.Sprintf(`[{"uri":%q,"range":{"start":{"line":%d,"character":5},"end":{"line":%d,"character":8}}}]`, fooURI, line, line)
+	}
+	assert.JSONEq(t, fooLocation(2), string(responses[2].Result))
+	assert.JSONEq(t, fooLocation(3), string(responses[3].Result))
+
+	var symbols []symbolInformation
+	assert.NoError(t, json.Unmarshal(responses[4].Result, &symbols))
+	assert.Equal(t, []symbolInformation{{
+		Name:          "bar",
+		Kind:          symbolKindFunction,
+		Location:      location{URI: fooURI, Range: textRange{Start: position{4, 5}, End: position{4, 8}}},
+		ContainerName: "main",
+	}}, symbols)
+
+	symbols = nil
+	assert.NoError(t, json.Unmarshal(responses[5].Result, &symbols))
+	var names []string
+	for _, symbol := range symbols {
+		names = append(names, symbol.Name)
+	}
+	assert.Equal(t, []string{"main", "main"}, names)
+
+	assert.Equal(t, errorCodeMethodNotFound, responses[6].Error.Code)
+	assert.Equal(t, "null", string(responses[7].Result))
+}
+
+func TestSymbolKind(t *testing.T) {
+	tests := []struct {
+		tag          common.TagEntry
+		expectedKind int
+	}{
+		{tag: common.TagEntry{Kind: "t"}, expectedKind: symbolKindClass},
+		{tag: common.TagEntry{Kind: "a"}, expectedKind: symbolKindClass},
+		{tag: common.TagEntry{Kind: "s"}, expectedKind: symbolKindStruct},
+		{tag: common.TagEntry{Kind: "f", ExtensionFields: map[string]string{"unkown": "main.T"}}, expectedKind: symbolKindMethod},
+		{tag: common.TagEntry{Kind: "f", ExtensionFields: map[string]string{"package": "main"}}, expectedKind: symbolKindFunction},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expectedKind, symbolKind(test.tag), test.tag.Kind)
+	}
+}
@@ -4,74 +4,607 @@ import (
 	"bufio"
 	"errors"
 	"flag"
+	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"path"
 	"slices"
-	"sort"
+	"strings"
 
 	common "github.com/jha-naman/tree-tags/common"
+	filelist "github.com/jha-naman/tree-tags/filelist"
 	golang "github.com/jha-naman/tree-tags/golang"
+	symboldb "github.com/jha-naman/tree-tags/symboldb"
+	tagfile "github.com/jha-naman/tree-tags/tagfile"
 )
 
 var options = common.Options{}
 
+const (
+	tagFileName           = "tags"
+	generatedTagFileName  = "tags.generated"
+	referencesTagFileName = "tags.references"
+	sqliteTagFileName     = "tags.db"
+)
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "query":
+			runQuery(os.Args[2:])
+			return
+		case "lsp":
+			runLanguageServer(os.Args[2:])
+			return
+		case "outline":
+			runOutline(os.Args[2:])
+			return
+		case "callgraph":
+			runCallgraph(os.Args[2:])
+			return
+		case "impls":
+			runImpls(os.Args[2:])
+			return
+		case "scip":
+			runSCIP(os.Args[2:])
+			return
+		}
+	}
 
 	initOptions()
 
+	if options.Stdin {
+		writeStdinTags()
+		return
+	}
+
+	if options.Filter {
+		writeFilterTags()
+		return
+	}
+
 	fileNames, err := getFileNames()
 	if err != nil {
 		log.Fatalf("error getting filenames: %s", err.Error())
 	}
 
+	if isStdoutOutputFormat() {
+		writeStdoutTags(fileNames)
+		return
+	}
+
+	// serialize concurrent runs, so that the tags added by an append run are
+	// not lost when another run reads the tags file or the source files
+	// before it has been replaced. Full runs take the lock too, before
+	// reading the source files, as the tags they write replace the ones of
+	// append runs.
+	unlock, err := tagfile.Lock(tagFileName)
+	if err != nil {
+		log.Fatal("error while trying to lock tag file:", err.Error())
+	}
+	defer unlock()
+
 	tags, err := initTags(fileNames)
 	if err != nil {
 		log.Fatal("error while initialising tags:", err.Error())
 	}
 
 	for _, fileName := range fileNames {
-		tags = append(tags, golang.GetFileTags(fileName)...)
+		// file lists like 'git diff --name-only' output also name deleted and
+		// other files, only their stale tags need to be removed
+		if !isTaggedFile(fileName) || !fileExists(fileName) {
+			continue
+		}
+
+		tags = append(tags, fileTags(fileName)...)
 	}
 
-	sort.Slice(tags, func(i, j int) bool {
-		return tags[i].Name < tags[j].Name
-	})
+	golang.SetImplementsFields(tags)
+	tags = filterTags(tags)
+
+	var references []common.TagEntry
+	if options.OutputFormat != common.OutputFormatJSON {
+		tags, references = partitionReferenceTags(tags)
+	}
+
+	if options.Generated == common.GeneratedExclude {
+		references, _ = partitionGeneratedTags(references)
+	}
+
+	if options.OutputFormat == common.OutputFormatSQLite {
+		// the database keeps the tags of generated files apart with the
+		// 'generated' column of the files table instead of another file
+		if options.Generated == common.GeneratedExclude {
+			tags, _ = partitionGeneratedTags(tags)
+		}
+
+		if err = writeSQLiteFile(sqliteTagFileName, tags, references); err != nil {
+			log.Fatal("error while trying to write tags database:", err.Error())
+		}
+
+		return
+	}
+
+	tags, generatedTags := partitionGeneratedTags(tags)
+
+	if err = writeTagFile(tagFileName, tags); err != nil {
+		log.Fatal("error while trying to write tag file:", err.Error())
+	}
+
+	if options.Generated == common.GeneratedSeparate {
+		if err = writeTagFile(generatedTagFileName, generatedTags); err != nil {
+			log.Fatal("error while trying to write generated tag file:", err.Error())
+		}
+	}
 
-	tagFile, err := os.Create("tags")
+	if options.References && options.OutputFormat == common.OutputFormatUCtags {
+		if err = writeTagFile(referencesTagFileName, references); err != nil {
+			log.Fatal("error while trying to write references tag file:", err.Error())
+		}
+	}
+}
+
+// writeStdinTags writes the tags for the go source read from stdin to stdout,
+// using the file name given with the 'filename' option for the tags. This lets
+// editors get the tags of modified buffers without saving them.
+func writeStdinTags() {
+	p, err := golang.NewProcessor(options.StdinFileName, os.Stdin, options)
 	if err != nil {
-		log.Fatal("error while trying to create tag file:", err.Error())
+		log.Fatal("error while trying to read stdin:", err.Error())
+	}
+
+	tags := p.GetTags()
+	golang.SetImplementsFields(tags)
+	tags = filterTags(tags)
+	tags = stdoutGeneratedTags(tags)
+
+	writer := bufio.NewWriter(os.Stdout)
+	if err = writeTags(writer, tags); err != nil {
+		log.Fatal("error while trying to write tags:", err.Error())
+	}
+
+	if err = writer.Flush(); err != nil {
+		log.Fatal("error while trying to write tags:", err.Error())
+	}
+}
+
+// writeFilterTags reads the names of files from stdin, one per line, and
+// writes the tags of each file to stdout followed by the terminator of the
+// 'filter-terminator' option, like 'ctags --filter'. This lets programs like
+// GNU GLOBAL run tree-tags as their parser, handing it one file at a time.
+func writeFilterTags() {
+	writer := bufio.NewWriter(os.Stdout)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fileName := scanner.Text()
+		if fileName == "" {
+			continue
+		}
+
+		var tags []common.TagEntry
+		if isTaggedFile(fileName) && fileExists(fileName) {
+			tags = fileTags(fileName)
+			golang.SetImplementsFields(tags)
+			tags = filterTags(tags)
+			tags = stdoutGeneratedTags(tags)
+		}
+
+		if err := writeTags(writer, tags); err != nil {
+			log.Fatal("error while trying to write tags:", err.Error())
+		}
+
+		if _, err := writer.WriteString(options.FilterTerminator); err != nil {
+			log.Fatal("error while trying to write tags:", err.Error())
+		}
+
+		// the reader waits for the terminator before sending the next file
+		if err := writer.Flush(); err != nil {
+			log.Fatal("error while trying to write tags:", err.Error())
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatal("error while trying to read file names:", err.Error())
+	}
+}
+
+// writeStdoutTags writes the tags of the files to stdout, for the output
+// formats which are listings for other programs instead of tags files.
+func writeStdoutTags(fileNames []string) {
+	var tags []common.TagEntry
+	for _, fileName := range fileNames {
+		if isTaggedFile(fileName) && fileExists(fileName) {
+			tags = append(tags, fileTags(fileName)...)
+		}
+	}
+
+	golang.SetImplementsFields(tags)
+	tags = filterTags(tags)
+	tags = stdoutGeneratedTags(tags)
+
+	writer := bufio.NewWriter(os.Stdout)
+	if err := writeTags(writer, tags); err != nil {
+		log.Fatal("error while trying to write tags:", err.Error())
+	}
+
+	if err := writer.Flush(); err != nil {
+		log.Fatal("error while trying to write tags:", err.Error())
+	}
+}
+
+// isStdoutOutputFormat reports whether the output format is written to stdout
+// instead of the tags file.
+func isStdoutOutputFormat() bool {
+	return options.OutputFormat == common.OutputFormatXref || options.OutputFormat == common.OutputFormatGtags
+}
+
+// fileTags returns the tags of a go file, or the tags of the regex
+// definitions for files without a grammar.
+func fileTags(fileName string) []common.TagEntry {
+	if path.Ext(fileName) != ".go" {
+		return regexFileTags(fileName)
+	}
+
+	return golang.GetFileTags(fileName, options)
+}
+
+// filterTags drops the tags left out by the 'exported-only', 'tests' and
+// 'kinds-go' options.
+func filterTags(tags []common.TagEntry) []common.TagEntry {
+	if options.ExportedOnly {
+		tags = exportedTags(tags)
+	}
+
+	tags = filterTestTags(tags)
+
+	return filterKinds(tags)
+}
+
+func exportedTags(tags []common.TagEntry) []common.TagEntry {
+	return slices.DeleteFunc(tags, func(tag common.TagEntry) bool {
+		return tag.ExtensionFields["access"] == "private"
+	})
+}
+
+// filterTestTags keeps or drops the tags from '_test.go' files according to
+// the 'tests' option.
+func filterTestTags(tags []common.TagEntry) []common.TagEntry {
+	if options.Tests == common.TestsInclude {
+		return tags
 	}
 
-	writer := bufio.NewWriter(tagFile)
+	return slices.DeleteFunc(tags, func(tag common.TagEntry) bool {
+		isTestFile := strings.HasSuffix(tag.FileName, "_test.go")
+		return isTestFile == (options.Tests == common.TestsExclude)
+	})
+}
+
+// writeTagFile writes the tags to a temporary file next to the tags file and
+// renames it into place, so that readers never see a partially written file.
+func writeTagFile(fileName string, tags []common.TagEntry) error {
+	return tagfile.Write(fileName, func(tagFile *os.File) error {
+		writer := bufio.NewWriter(tagFile)
+		if options.OutputFormat == common.OutputFormatUCtags {
+			if err := writePseudoTags(writer); err != nil {
+				return err
+			}
+		}
+
+		if err := writeTags(writer, tags); err != nil {
+			return err
+		}
 
+		return writer.Flush()
+	})
+}
+
+// writeSQLiteFile writes the tags and reference tags to a new sqlite database
+// and renames it into place, like writeTagFile.
+func writeSQLiteFile(fileName string, tags, references []common.TagEntry) error {
+	return tagfile.Write(fileName, func(dbFile *os.File) error {
+		return symboldb.Write(dbFile.Name(), enabledFieldTags(tags), enabledFieldTags(references), goKinds(), options.FullDoc)
+	})
+}
+
+// enabledFieldTags returns copies of the tags with only the extension fields
+// enabled by the 'fields' option.
+func enabledFieldTags(tags []common.TagEntry) []common.TagEntry {
+	enabled := make([]common.TagEntry, len(tags))
+	for i, tag := range tags {
+		enabled[i] = tag.WithEnabledFields(golang.Fields, options.Fields)
+	}
+
+	return enabled
+}
+
+// writePseudoTags writes the '!_TAG_' lines describing the tags file, which
+// tell readers like vim whether they can binary search the file.
+func writePseudoTags(writer io.Writer) error {
+	_, err := fmt.Fprintf(writer,
+		"!_TAG_FILE_FORMAT\t2\t/extended format; --format=1 will not append ;\" to lines/\n"+
+			"!_TAG_FILE_SORTED\t%s\t/0=unsorted, 1=sorted, 2=foldcase/\n",
+		common.SortedPseudoTagValue(options.Sort))
+
+	return err
+}
+
+// writeTags sorts the tags according to the 'sort' option and writes them,
+// one per line, in the format given by the 'output-format' option.
+func writeTags(writer io.Writer, tags []common.TagEntry) error {
+	common.SortTags(tags, options.Sort)
+
+	var err error
 	for _, tag := range tags {
-		if _, err = writer.Write(append(tag.Bytes(), []byte("\n")...)); err != nil {
-			log.Fatal("error while trying to write tag file:", err.Error())
+		var tagBytes []byte
+		switch options.OutputFormat {
+		case common.OutputFormatJSON:
+			if tagBytes, err = tag.WithEnabledFields(golang.Fields, options.Fields).JSONBytes(options.FullDoc, goKinds()); err != nil {
+				return err
+			}
+		case common.OutputFormatGtags:
+			// the line numbers and roles are part of the protocol
+			tagBytes = tag.GtagsBytes()
+		case common.OutputFormatXref:
+			// the xref format names the fields it prints, like the line
+			// number, so they are printed whether they are enabled or not
+			tagBytes = options.XrefFormat.Bytes(tag, goKinds())
+		default:
+			tagBytes = tag.WithEnabledFields(golang.Fields, options.Fields).Bytes()
+		}
+
+		if _, err = writer.Write(append(tagBytes, []byte("\n")...)); err != nil {
+			return err
 		}
 	}
 
-	if err = writer.Flush(); err != nil {
-		log.Fatal("error while trying to write tag file:", err.Error())
+	return nil
+}
+
+// partitionGeneratedTags splits off the tags extracted from generated files
+// according to the 'generated' option. Tags from generated files are only
+// returned separately in 'separate' mode and dropped altogether in 'exclude' mode.
+func partitionGeneratedTags(tags []common.TagEntry) (handWritten, generated []common.TagEntry) {
+	if options.Generated == common.GeneratedInclude {
+		return tags, nil
+	}
+
+	for _, tag := range tags {
+		if tag.ExtensionFields["generated"] == "yes" {
+			generated = append(generated, tag)
+		} else {
+			handWritten = append(handWritten, tag)
+		}
+	}
+
+	if options.Generated == common.GeneratedExclude {
+		generated = nil
 	}
+
+	return handWritten, generated
+}
+
+// stdoutGeneratedTags drops the tags from generated files in 'exclude' mode,
+// for the output to stdout, which has no separate file for them in 'separate'
+// mode.
+func stdoutGeneratedTags(tags []common.TagEntry) []common.TagEntry {
+	tags, generatedTags := partitionGeneratedTags(tags)
+	if options.Generated == common.GeneratedSeparate {
+		tags = append(tags, generatedTags...)
+	}
+
+	return tags
+}
+
+// partitionReferenceTags splits off the reference tags, having the 'roles:ref'
+// field, which are written to their own file in the u-ctags format.
+func partitionReferenceTags(tags []common.TagEntry) (definitions, references []common.TagEntry) {
+	for _, tag := range tags {
+		if tag.ExtensionFields["roles"] == "ref" {
+			references = append(references, tag)
+		} else {
+			definitions = append(definitions, tag)
+		}
+	}
+
+	return definitions, references
 }
 
 func initOptions() {
 	flag.BoolVar(&options.AppendMode, "a", false, "shorthand form for 'append' option")
 	flag.BoolVar(&options.AppendMode, "append", false, "add this flag to re-generate tags for given list of files instead of re-generating the tags file from scratch for the whole project, will remove stale tags belonging to the given list of files")
 
-	flag.Parse()
+	flag.BoolVar(&options.Prune, "prune", false, "in append mode, also remove the tags of files which no longer exist")
+	flag.StringVar(&options.FilesFrom, "L", "", "shorthand form for 'files-from' option")
+	flag.StringVar(&options.FilesFrom, "files-from", "", "in append mode, read the names of the files to re-generate tags for from the given file, one per line, or from stdin for '-', e.g. 'git diff --name-only | tree-tags -a -L -'")
+	flag.StringVar(&options.Generated, "generated", common.GeneratedInclude, "how to handle tags from generated files (having a '// Code generated ... DO NOT EDIT.' header), one of 'include', 'exclude' or 'separate'. 'separate' writes them to the '"+generatedTagFileName+"' file")
+	flag.StringVar(&options.OutputFormat, "output-format", common.OutputFormatUCtags, "format of the tags file, one of 'u-ctags', 'json', 'sqlite', 'xref' or 'gtags'. 'xref' and 'gtags' write a listing to stdout, like 'ctags -x', 'gtags' in the format of the GNU GLOBAL plug-in parser protocol. 'sqlite' writes a database with the symbols, files, scopes and refs tables to the '"+sqliteTagFileName+"' file")
+	xref := flag.Bool("x", false, "shorthand form for the 'xref' output format, printing the name, kind, line, file and source line of the tags to stdout, sorted according to the 'sort' option")
+	xrefFormat := flag.String("_xformat", "", "format of the lines of the 'xref' output format, like u-ctags '--_xformat', e.g. '%-20N %4n %{doc}', default '"+common.DefaultXrefFormat+"'")
+	flag.BoolVar(&options.FullDoc, "doc-full", false, "write the full text of doc comments, instead of the one line summary, to the 'doc' field of the json output")
+	flag.BoolVar(&options.StructTagAliases, "struct-tag-aliases", false, "add tags named after the json, yaml and db struct tag names of struct fields, pointing to the struct fields")
+	flag.BoolVar(&options.ExportedOnly, "exported-only", false, "only add tags for exported identifiers, i.e. the ones with the 'access:public' field, and the tags of packages and import names, which have no 'access' field")
+	flag.StringVar(&options.Tests, "tests", common.TestsInclude, "how to handle tags from '_test.go' files, one of 'include', 'exclude' or 'only'")
+	flag.IntVar(&options.PatternLengthLimit, "pattern-length-limit", 96, "cut off the search patterns of the tags after this many bytes of the line, 0 for no limit")
+	flag.StringVar(&options.Sort, "sort", common.SortYes, "how to sort the tags, one of 'yes' for sorting by name, file and line, 'foldcase' for sorting the names case insensitively, or 'no' to keep the order of the files")
+	flag.Func("tags-query", "add tags for the definitions found by the tree-sitter tags query in the file, given as 'LANGUAGE:FILE', e.g. 'go:routes.scm'. Can be repeated, 'go' is the only supported language", addTagsQuery)
+	kindsSelection := flag.String("kinds-go", "*", "kinds of tags to write for go files, by letter, e.g. '+f-v' to add functions and drop variables, 'fsi' for only functions, structs and interfaces, or '*' for all")
+	fieldsSelection := flag.String("fields", "", "extension fields to write, by letter or '{name}', e.g. '+S-t' to add signatures and drop types, or '-{doc}' to drop the doc field")
+	listKinds := flag.Bool("list-kinds", false, "list the kinds of tags of go files and exit")
+	listFields := flag.Bool("list-fields", false, "list the extension fields and exit")
+	flag.BoolVar(&options.References, "references", false, "also add reference tags, with the 'roles:ref' field and the enclosing function as scope, for the identifiers, calls and types used in function bodies. They are written to the '"+referencesTagFileName+"' file, or with the other tags in the json output format")
+	flag.BoolVar(&options.Filter, "filter", false, "read file names from stdin, one per line, and write the tags of each file to stdout followed by the 'filter-terminator', for running tree-tags as the parser of GNU GLOBAL or other programs")
+	flag.StringVar(&options.FilterTerminator, "filter-terminator", "", "string written after the tags of each file in filter mode, e.g. '###terminator###\\n' with the newline given as '\\n'")
+	flag.BoolVar(&options.Stdin, "stdin", false, "read go source from stdin and write its tags to stdout instead of the tags file, needs the 'filename' option")
+	flag.StringVar(&options.StdinFileName, "filename", "", "file name to use in the tags for the source read with the 'stdin' option")
+
+	args, err := optionArgs()
+	if err != nil {
+		log.Fatal("error while reading options:", err.Error())
+	}
+
+	flag.CommandLine.Parse(args)
+
+	if *xref {
+		options.OutputFormat = common.OutputFormatXref
+	}
+
+	if err = initKindsAndFields(*kindsSelection, *fieldsSelection); err != nil {
+		log.Fatal(err.Error())
+	}
+
+	if *listKinds || *listFields {
+		if *listKinds {
+			writeKindList(os.Stdout)
+		}
+		if *listFields {
+			writeFieldList(os.Stdout)
+		}
+		os.Exit(0)
+	}
+
+	switch options.Generated {
+	case common.GeneratedInclude, common.GeneratedExclude, common.GeneratedSeparate:
+	default:
+		log.Fatalf("invalid value %q for 'generated' option, should be one of 'include', 'exclude' or 'separate'", options.Generated)
+	}
+
+	switch options.Tests {
+	case common.TestsInclude, common.TestsExclude, common.TestsOnly:
+	default:
+		log.Fatalf("invalid value %q for 'tests' option, should be one of 'include', 'exclude' or 'only'", options.Tests)
+	}
+
+	switch options.Sort {
+	case common.SortYes, common.SortNo, common.SortFoldcase:
+	default:
+		log.Fatalf("invalid value %q for 'sort' option, should be one of 'yes', 'no' or 'foldcase'", options.Sort)
+	}
+
+	if options.PatternLengthLimit < 0 {
+		log.Fatalf("invalid value %d for 'pattern-length-limit' option, should not be negative", options.PatternLengthLimit)
+	}
+
+	if options.FilesFrom != "" && !options.AppendMode {
+		log.Fatal("the 'files-from' option can only be used in append mode")
+	}
+
+	if options.Prune && !options.AppendMode {
+		log.Fatal("the 'prune' option can only be used in append mode")
+	}
+
+	if options.Stdin {
+		if options.StdinFileName == "" {
+			log.Fatal("need to supply a file name with the 'filename' option when reading from stdin")
+		}
+
+		if options.AppendMode {
+			log.Fatal("append mode is not supported when reading from stdin")
+		}
+	} else if options.StdinFileName != "" {
+		log.Fatal("the 'filename' option can only be used with the 'stdin' option")
+	}
+
+	if options.Filter {
+		if options.AppendMode || options.Stdin {
+			log.Fatal("the 'filter' option cannot be used with append mode or the 'stdin' option")
+		}
+
+		// the newline ending terminators like '###terminator###\n' can also
+		// be given escaped, which is easier in shells
+		options.FilterTerminator = strings.ReplaceAll(options.FilterTerminator, `\n`, "\n")
+	} else if options.FilterTerminator != "" {
+		log.Fatal("the 'filter-terminator' option can only be used with the 'filter' option")
+	}
+
+	switch options.OutputFormat {
+	case common.OutputFormatUCtags:
+	case common.OutputFormatJSON:
+		if options.AppendMode {
+			log.Fatal("append mode is not supported with the json output format")
+		}
+	case common.OutputFormatSQLite:
+		if options.AppendMode {
+			log.Fatal("append mode is not supported with the sqlite output format")
+		}
+
+		if options.Stdin || options.Filter {
+			log.Fatal("the sqlite output format cannot be written to stdout, it is not supported with the 'stdin' and 'filter' options")
+		}
+
+		if options.Generated == common.GeneratedSeparate {
+			log.Fatal("the sqlite output format has no separate file for generated files, use the 'generated' column of the files table with the 'include' mode of the 'generated' option instead")
+		}
+	case common.OutputFormatGtags, common.OutputFormatXref:
+		if options.AppendMode {
+			log.Fatalf("append mode is not supported with the %s output format", options.OutputFormat)
+		}
+	default:
+		log.Fatalf("invalid value %q for 'output-format' option, should be one of 'u-ctags', 'json', 'sqlite', 'xref' or 'gtags'", options.OutputFormat)
+	}
+
+	if options.OutputFormat == common.OutputFormatXref {
+		if *xrefFormat == "" {
+			*xrefFormat = common.DefaultXrefFormat
+		}
+
+		if options.XrefFormat, err = common.ParseXrefFormat(*xrefFormat, golang.Fields); err != nil {
+			log.Fatalf("invalid value %q for '_xformat' option: %s", *xrefFormat, err.Error())
+		}
+	} else if *xrefFormat != "" {
+		log.Fatal("the '_xformat' option can only be used with the xref output format")
+	}
+
+	// the xref and gtags output formats print the source lines in full, not
+	// cut off like the patterns
+	options.SourceLines = isStdoutOutputFormat()
+}
+
+// addTagsQuery reads and checks the query file given to the 'tags-query'
+// option.
+func addTagsQuery(value string) error {
+	language, queryFileName, found := strings.Cut(value, ":")
+	if !found || queryFileName == "" {
+		return errors.New("should be given as 'LANGUAGE:FILE'")
+	}
+
+	if language != "go" {
+		return fmt.Errorf("unsupported language %q, only 'go' is supported", language)
+	}
+
+	source, err := os.ReadFile(queryFileName)
+	if err != nil {
+		return err
+	}
+
+	if _, err = golang.NewTagsQuery(source); err != nil {
+		return fmt.Errorf("%s: %w", queryFileName, err)
+	}
+
+	options.TagsQueries = append(options.TagsQueries, string(source))
+
+	return nil
 }
 
 func getFileNames() ([]string, error) {
 	if options.AppendMode {
 		fileNames := flag.Args()
+		if options.FilesFrom != "" {
+			listedFileNames, err := readFileList(options.FilesFrom)
+			if err != nil {
+				return nil, err
+			}
+
+			fileNames = append(fileNames, listedFileNames...)
+		}
+
 		if len(fileNames) == 0 {
 			log.Fatal("need to supply file names when used in append mode (using -a as command line option)")
 		}
 
-		return fileNames, nil
+		return filelist.Normalize(fileNames), nil
 	}
 
 	wd, err := os.Getwd()
@@ -86,8 +619,7 @@ func getFileNames() ([]string, error) {
 			return err
 		}
 
-		ext := path.Ext(filePath)
-		if !d.IsDir() && ext == ".go" {
+		if !d.IsDir() && isTaggedFile(filePath) {
 			matchingFiles = append(matchingFiles, filePath)
 		}
 
@@ -97,6 +629,28 @@ func getFileNames() ([]string, error) {
 	return matchingFiles, nil
 }
 
+// readFileList reads the names of the files to tag, one per line, from the
+// given file or from stdin for '-'.
+func readFileList(listFileName string) ([]string, error) {
+	reader := io.Reader(os.Stdin)
+	if listFileName != "-" {
+		file, err := os.Open(listFileName)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		reader = file
+	}
+
+	return filelist.Read(reader)
+}
+
+func fileExists(fileName string) bool {
+	_, err := os.Stat(fileName)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
 func initTags(fileNamesToSkip []string) ([]common.TagEntry, error) {
 	tags := []common.TagEntry{}
 
@@ -104,26 +658,66 @@ func initTags(fileNamesToSkip []string) ([]common.TagEntry, error) {
 		return tags, nil
 	}
 
-	file, err := os.Open("tags")
+	// with the 'prune' option the tags of files which no longer exist are
+	// dropped as well
+	keep := filelist.KeepTag(fileNamesToSkip, options.Prune, fileExists)
+
+	tagFileNames := []string{tagFileName}
+	if options.Generated == common.GeneratedSeparate {
+		tagFileNames = append(tagFileNames, generatedTagFileName)
+	}
+
+	if options.References {
+		tagFileNames = append(tagFileNames, referencesTagFileName)
+	}
+
+	for _, fileName := range tagFileNames {
+		fileTags, err := readTagFile(fileName, keep)
+		if err != nil {
+			return nil, err
+		}
+
+		tags = append(tags, fileTags...)
+	}
+
+	return tags, nil
+}
+
+// readTagFile returns the tags of the tags file for which keep returns true.
+func readTagFile(fileName string, keep func(common.TagEntry) bool) ([]common.TagEntry, error) {
+	tags := []common.TagEntry{}
+
+	file, err := os.Open(fileName)
 	if err != nil {
 		if !errors.Is(err, fs.ErrNotExist) {
 			return nil, err
 		}
+
+		return tags, nil
 	}
+	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
 
-	for scanner.Scan() {
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
 		text := scanner.Text()
 		tag, err := common.TagFromString(text)
 		if errors.Is(err, common.ErrStringIsAComment) {
 			continue
 		}
+		if err != nil {
+			// a single malformed line, e.g. from another tags generator,
+			// should not keep the rest of the tags from being updated
+			log.Printf("skipping invalid tag at %s:%d: %s", fileName, lineNumber, err.Error())
+			continue
+		}
 
-		if !slices.Contains(fileNamesToSkip, tag.FileName) {
+		if keep(tag) {
 			tags = append(tags, tag)
 		}
 	}
 
-	return tags, nil
+	return tags, scanner.Err()
 }
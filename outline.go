@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+	outline "github.com/jha-naman/tree-tags/outline"
+)
+
+// runOutline implements the 'outline' subcommand, printing the tags of a
+// single file arranged by scope.
+func runOutline(args []string) {
+	flagSet := flag.NewFlagSet("outline", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: tree-tags outline [options] FILE\n       tree-tags outline [options] --stdin-filename FILE < FILE")
+		flagSet.PrintDefaults()
+	}
+
+	var stdinFileName string
+	var jsonOutput bool
+	flagSet.StringVar(&stdinFileName, "stdin-filename", "", "read the file contents from stdin, using this name for the file")
+	flagSet.BoolVar(&jsonOutput, "json", false, "print the outline as json instead of indented text")
+
+	fileNames := parseInterspersed(flagSet, subcommandArgs(flagSet, args))
+
+	var fileName string
+	var reader io.Reader
+	switch {
+	case stdinFileName != "" && len(fileNames) == 0:
+		fileName, reader = stdinFileName, os.Stdin
+	case stdinFileName == "" && len(fileNames) == 1:
+		file, err := os.Open(fileNames[0])
+		if err != nil {
+			log.Fatal("error while trying to read file:", err.Error())
+		}
+		defer file.Close()
+
+		fileName, reader = fileNames[0], file
+	default:
+		flagSet.Usage()
+		os.Exit(2)
+	}
+
+	p, err := golang.NewProcessor(fileName, reader, common.Options{})
+	if err != nil {
+		log.Fatal("error while trying to read file:", err.Error())
+	}
+
+	root := outline.Build(p.GetTags(), golang.Kinds)
+
+	writer := bufio.NewWriter(os.Stdout)
+	if jsonOutput {
+		err = outline.WriteJSON(writer, root)
+	} else {
+		err = outline.WriteText(writer, root)
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err != nil {
+		log.Fatal("error while writing outline:", err.Error())
+	}
+}
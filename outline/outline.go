@@ -0,0 +1,183 @@
+// Package outline arranges the tags of a single file in a tree, the way
+// outline and tagbar like editor sidebars show them: the package, its types
+// with their fields and methods, and the functions, variables and constants.
+package outline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+type Node struct {
+	Name     string  `json:"name"`
+	Kind     string  `json:"kind"`
+	Line     int     `json:"line"`
+	End      int     `json:"end"`
+	Scope    string  `json:"scope,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// fields naming the type a tag belongs to, methods have their receiver type
+// in the 'unkown' field
+var typeScopeFieldNames = []string{"struct", "interface", "unkown"}
+
+// Build returns the outline of the tags of a file. The package is the root
+// of the outline, or an unnamed node if the tags have no package tag. Kinds
+// are given by their long names from the kinds list.
+func Build(tags []common.TagEntry, kinds []common.Kind) *Node {
+	root := &Node{}
+	types := map[string]*Node{}
+
+	tags = sortedByLine(tags)
+
+	for _, tag := range tags {
+		if tag.Kind == "p" {
+			root.Name, root.Kind = tag.Name, common.KindName(kinds, tag.Kind)
+			root.Line, root.End = tagLines(tag)
+		}
+	}
+
+	var members []common.TagEntry
+	for _, tag := range tags {
+		switch tag.Kind {
+		case "p", "P":
+			// the package is the root and imports are not part of the outline
+			continue
+		}
+
+		if typeScope(tag) != "" {
+			members = append(members, tag)
+			continue
+		}
+
+		node := newNode(tag, kinds)
+		root.Children = append(root.Children, node)
+
+		if packageName := tag.ExtensionFields["package"]; packageName != "" {
+			types[packageName+"."+tag.Name] = node
+		}
+	}
+
+	for _, tag := range members {
+		node := newNode(tag, kinds)
+
+		parent, ok := types[common.ReceiverTypeName(typeScope(tag))]
+		if !ok {
+			// methods on types declared in other files of the package
+			parent = root
+		}
+
+		parent.Children = append(parent.Children, node)
+	}
+
+	sortChildren(root)
+
+	// the package clause is a single line, the package spans the whole file
+	root.End = lastLine(root)
+
+	return root
+}
+
+// lastLine returns the last line of the node and its descendants, methods
+// can come after the end of their type.
+func lastLine(node *Node) int {
+	end := node.End
+	for _, child := range node.Children {
+		end = max(end, lastLine(child))
+	}
+
+	return end
+}
+
+func newNode(tag common.TagEntry, kinds []common.Kind) *Node {
+	line, end := tagLines(tag)
+	return &Node{
+		Name:  tag.Name,
+		Kind:  common.KindName(kinds, tag.Kind),
+		Line:  line,
+		End:   end,
+		Scope: typeScope(tag),
+	}
+}
+
+func typeScope(tag common.TagEntry) string {
+	for _, fieldName := range typeScopeFieldNames {
+		if scope, ok := tag.ExtensionFields[fieldName]; ok {
+			return scope
+		}
+	}
+
+	return ""
+}
+
+// tagLines returns the lines from the 'line' and 'end' fields, tags without
+// an 'end' field end on the line they start on.
+func tagLines(tag common.TagEntry) (line, end int) {
+	line, _ = strconv.Atoi(tag.ExtensionFields["line"])
+	end, err := strconv.Atoi(tag.ExtensionFields["end"])
+	if err != nil {
+		end = line
+	}
+
+	return line, end
+}
+
+func sortedByLine(tags []common.TagEntry) []common.TagEntry {
+	sorted := make([]common.TagEntry, len(tags))
+	copy(sorted, tags)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		lineI, _ := tagLines(sorted[i])
+		lineJ, _ := tagLines(sorted[j])
+		return lineI < lineJ
+	})
+
+	return sorted
+}
+
+func sortChildren(node *Node) {
+	sort.SliceStable(node.Children, func(i, j int) bool {
+		return node.Children[i].Line < node.Children[j].Line
+	})
+
+	for _, child := range node.Children {
+		sortChildren(child)
+	}
+}
+
+// WriteText writes the outline indenting the children under their parents,
+// with the lines each node spans.
+func WriteText(writer io.Writer, root *Node) error {
+	return writeTextNode(writer, root, 0)
+}
+
+func writeTextNode(writer io.Writer, node *Node, depth int) error {
+	lines := strconv.Itoa(node.Line)
+	if node.End != node.Line {
+		lines = fmt.Sprintf("%d-%d", node.Line, node.End)
+	}
+
+	if _, err := fmt.Fprintf(writer, "%s%s %s %s\n", strings.Repeat("  ", depth), node.Name, node.Kind, lines); err != nil {
+		return err
+	}
+
+	for _, child := range node.Children {
+		if err := writeTextNode(writer, child, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func WriteJSON(writer io.Writer, root *Node) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(root)
+}
@@ -0,0 +1,67 @@
+package outline
+
+import (
+	"bytes"
+	"testing"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+	input := `package main
+
+type Foo struct {
+	X int
+}
+
+func (f *Foo) Bar() {
+}
+
+var v = 1
+
+func main() {}
+
+func (b Baz) Qux() {}
+`
+
+	p := golang.NewProcessorFromBytes("main.go", []byte(input), common.Options{})
+
+	var text bytes.Buffer
+	assert.NoError(t, WriteText(&text, Build(p.GetTags(), golang.Kinds)))
+	assert.Equal(t, `main package 1-14
+  Foo struct 3-5
+    X member 4
+    Bar func 7-8
+  v var 10
+  main func 12
+  Qux func 14
+`, text.String())
+}
+
+func TestBuildGenericType(t *testing.T) {
+	input := `package main
+
+type G[T any] struct {
+	V T
+}
+
+func (g *G[T]) Get() T {
+	return g.V
+}
+
+func (g G[T]) Len() int { return 1 }
+`
+
+	p := golang.NewProcessorFromBytes("main.go", []byte(input), common.Options{})
+
+	var text bytes.Buffer
+	assert.NoError(t, WriteText(&text, Build(p.GetTags(), golang.Kinds)))
+	assert.Equal(t, `main package 1-11
+  G struct 3-5
+    V member 4
+    Get func 7-9
+    Len func 11
+`, text.String())
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	golang "github.com/jha-naman/tree-tags/golang"
+	readtags "github.com/jha-naman/tree-tags/readtags"
+)
+
+// runQuery implements the 'query' subcommand, looking up tags by name in an
+// existing tags file.
+func runQuery(args []string) {
+	flagSet := flag.NewFlagSet("query", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: tree-tags query [options] NAME")
+		flagSet.PrintDefaults()
+	}
+
+	query := readtags.Query{Match: readtags.MatchExact}
+	var tagFile string
+	var prefix, regex, jsonOutput bool
+
+	flagSet.StringVar(&tagFile, "t", tagFileName, "tags file to search")
+	flagSet.BoolVar(&prefix, "prefix", false, "match tags whose names start with NAME")
+	flagSet.BoolVar(&regex, "regex", false, "match tags whose names match the regular expression NAME")
+	flagSet.BoolVar(&query.IgnoreCase, "i", false, "match names case insensitively")
+	flagSet.StringVar(&query.Kind, "kind", "", "only print tags of this kind")
+	flagSet.StringVar(&query.Scope, "scope", "", "only print tags in this scope, e.g. 'main.Foo' or 'struct:main.Foo'")
+	flagSet.StringVar(&query.FileName, "file", "", "only print tags from this file")
+	flagSet.BoolVar(&jsonOutput, "json", false, "print the tags as json lines instead of the lines of the tags file")
+
+	names := parseInterspersed(flagSet, subcommandArgs(flagSet, args))
+	if len(names) != 1 {
+		flagSet.Usage()
+		os.Exit(2)
+	}
+
+	query.Name = names[0]
+	switch {
+	case prefix && regex:
+		log.Fatal("only one of 'prefix' and 'regex' options can be used")
+	case prefix:
+		query.Match = readtags.MatchPrefix
+	case regex:
+		query.Match = readtags.MatchRegex
+	}
+
+	results, err := readtags.Search(tagFile, query)
+	if err != nil {
+		log.Fatal("error while searching tags:", err.Error())
+	}
+
+	writer := bufio.NewWriter(os.Stdout)
+	for _, result := range results {
+		line := []byte(result.Line)
+		if jsonOutput {
+			if line, err = result.Tag.JSONBytes(false, golang.Kinds); err != nil {
+				log.Fatal("error while writing tag:", err.Error())
+			}
+		}
+
+		if _, err = writer.Write(append(line, '\n')); err != nil {
+			log.Fatal("error while writing tag:", err.Error())
+		}
+	}
+
+	if err = writer.Flush(); err != nil {
+		log.Fatal("error while writing tag:", err.Error())
+	}
+
+	if len(results) == 0 {
+		os.Exit(1)
+	}
+}
+
+// parseInterspersed parses the flags of a subcommand, allowing them to come
+// after the positional arguments too, and returns the positional arguments.
+func parseInterspersed(flagSet *flag.FlagSet, args []string) []string {
+	var positionalArgs []string
+
+	for {
+		// ExitOnError flag sets exit on invalid flags
+		_ = flagSet.Parse(args)
+		if flagSet.NArg() == 0 {
+			return positionalArgs
+		}
+
+		positionalArgs = append(positionalArgs, flagSet.Arg(0))
+		args = flagSet.Args()[1:]
+	}
+}
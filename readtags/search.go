@@ -0,0 +1,285 @@
+// Package readtags looks up tags in a tags file, like the readtags command
+// that comes with u-ctags. Sorted tags files are binary searched instead of
+// being read completely, following their '!_TAG_FILE_SORTED' pseudo tag.
+package readtags
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+)
+
+const (
+	MatchExact  = "exact"
+	MatchPrefix = "prefix"
+	MatchRegex  = "regex"
+)
+
+type Query struct {
+	Name       string
+	Match      string
+	IgnoreCase bool
+	Kind       string
+	Scope      string
+	FileName   string
+}
+
+// Result is a matching tag along with the line of the tags file it was read from.
+type Result struct {
+	Line string
+	Tag  common.TagEntry
+}
+
+// ScopeFieldNames are the extension fields naming the scope a tag is defined in.
+var ScopeFieldNames = []string{"package", "struct", "interface", "unkown"}
+
+// Search returns the tags in the given tags file matching the query, in the
+// order they appear in the file.
+func Search(tagFileName string, query Query) ([]Result, error) {
+	file, err := os.Open(tagFileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	nameMatcher, err := newNameMatcher(query)
+	if err != nil {
+		return nil, err
+	}
+
+	sortMode, err := fileSortMode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	// foldcase sorted files keep the names differing only in case together,
+	// so they can be binary searched for case insensitive queries too
+	foldcase := sortMode == common.SortFoldcase
+	searchName := query.Name
+	if foldcase {
+		searchName = common.FoldCase(searchName)
+	}
+
+	var startOffset int64
+	canBinarySearch := query.Match != MatchRegex && sortMode != common.SortNo && (foldcase || !query.IgnoreCase)
+	if canBinarySearch {
+		startOffset, err = firstLineNotBefore(file, stat.Size(), searchName, foldcase)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	inSearchedRange := func(name string) bool {
+		if foldcase {
+			name = common.FoldCase(name)
+		}
+
+		if query.Match == MatchPrefix {
+			return strings.HasPrefix(name, searchName)
+		}
+
+		return name == searchName
+	}
+
+	results := []Result{}
+	scanner := bufio.NewScanner(io.NewSectionReader(file, startOffset, stat.Size()-startOffset))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		name, _, _ := strings.Cut(line, "\t")
+
+		// in a sorted file the matching names are next to each other
+		if canBinarySearch && !strings.HasPrefix(line, "!_") && !inSearchedRange(name) {
+			break
+		}
+
+		if !nameMatcher(name) {
+			continue
+		}
+
+		tag, err := common.TagFromString(line)
+		if errors.Is(err, common.ErrStringIsAComment) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if query.matchesFilters(tag) {
+			results = append(results, Result{Line: line, Tag: tag})
+		}
+	}
+
+	return results, scanner.Err()
+}
+
+func newNameMatcher(query Query) (func(name string) bool, error) {
+	switch query.Match {
+	case MatchRegex:
+		pattern := query.Name
+		if query.IgnoreCase {
+			pattern = "(?i)" + pattern
+		}
+
+		nameRegex, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+
+		return nameRegex.MatchString, nil
+	case MatchPrefix:
+		if query.IgnoreCase {
+			return func(name string) bool {
+				return len(name) >= len(query.Name) && strings.EqualFold(name[:len(query.Name)], query.Name)
+			}, nil
+		}
+
+		return func(name string) bool { return strings.HasPrefix(name, query.Name) }, nil
+	case MatchExact, "":
+		if query.IgnoreCase {
+			return func(name string) bool { return strings.EqualFold(name, query.Name) }, nil
+		}
+
+		return func(name string) bool { return name == query.Name }, nil
+	}
+
+	return nil, errors.New("invalid match mode " + query.Match + ", should be one of exact, prefix or regex")
+}
+
+func (query Query) matchesFilters(tag common.TagEntry) bool {
+	if query.Kind != "" && tag.Kind != query.Kind {
+		return false
+	}
+
+	if query.FileName != "" && filepath.Clean(tag.FileName) != filepath.Clean(query.FileName) {
+		return false
+	}
+
+	if query.Scope != "" {
+		return matchesScope(tag, query.Scope)
+	}
+
+	return true
+}
+
+// matchesScope matches the scope either as a plain name like 'main.Foo' or
+// along with the scope kind like 'struct:main.Foo'.
+func matchesScope(tag common.TagEntry, scope string) bool {
+	for _, fieldName := range ScopeFieldNames {
+		value, ok := tag.ExtensionFields[fieldName]
+		if ok && (value == scope || fieldName+":"+value == scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fileSortMode returns how the tags file is sorted according to its
+// '!_TAG_FILE_SORTED' pseudo tag. Files without it are taken to be sorted.
+func fileSortMode(file *os.File) (string, error) {
+	scanner := bufio.NewScanner(io.NewSectionReader(file, 0, 1<<62))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "!_") {
+			break
+		}
+
+		if value, found := strings.CutPrefix(line, "!_TAG_FILE_SORTED\t"); found {
+			switch value, _, _ := strings.Cut(value, "\t"); value {
+			case "0":
+				return common.SortNo, nil
+			case "2":
+				return common.SortFoldcase, nil
+			}
+
+			return common.SortYes, nil
+		}
+	}
+
+	return common.SortYes, scanner.Err()
+}
+
+// firstLineNotBefore returns the offset of the first line in the sorted tags
+// file whose tag name is not less than the given name, comparing the names
+// case insensitively for foldcase sorted files.
+func firstLineNotBefore(file *os.File, size int64, name string, foldcase bool) (int64, error) {
+	var searchErr error
+
+	offset := sort.Search(int(size)+1, func(position int) bool {
+		if searchErr != nil {
+			return true
+		}
+
+		_, line, err := lineStartingAfter(file, int64(position))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+
+		if line == nil {
+			return true
+		}
+
+		lineName, _, _ := strings.Cut(string(line), "\t")
+		if foldcase {
+			lineName = common.FoldCase(lineName)
+		}
+
+		return lineName >= name
+	})
+	if searchErr != nil {
+		return 0, searchErr
+	}
+
+	lineOffset, _, err := lineStartingAfter(file, int64(offset))
+	return lineOffset, err
+}
+
+// lineStartingAfter returns the first line starting at, or after, the given
+// position in the file. The returned line is nil at the end of the file.
+func lineStartingAfter(file *os.File, position int64) (int64, []byte, error) {
+	lineStart := position
+	if position > 0 {
+		// the line starts right after the newline ending the previous one
+		lineStart = position - 1
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, lineStart, 1<<62))
+	if position > 0 {
+		skipped, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return lineStart + int64(len(skipped)), nil, nil
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		lineStart += int64(len(skipped))
+	}
+
+	line, err := reader.ReadBytes('\n')
+	if errors.Is(err, io.EOF) {
+		if len(line) == 0 {
+			return lineStart, nil, nil
+		}
+		err = nil
+	}
+
+	return lineStart, bytes.TrimRight(line, "\r\n"), err
+}
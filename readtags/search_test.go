@@ -0,0 +1,103 @@
+package readtags
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var tagLines = []string{
+	"!_TAG_FILE_FORMAT\t2\t/extended format/",
+	"Bar\tbar.go\t/^func Bar() {}$/;\"\tf\tpackage:main",
+	"Foo\tfoo.go\t/^type Foo struct {$/;\"\ts\tpackage:main",
+	"Foo\tfoo_test.go\t/^func Foo() {}$/;\"\tf\tpackage:main_test",
+	"FooBar\tfoo.go\t/^\tFooBar int$/;\"\tm\tstruct:main.Foo",
+	"Zed\tzed.go\t/^var Zed int$/;\"\tv\tpackage:main",
+	"foo\tfoo.go\t/^var foo int$/;\"\tv\tpackage:main",
+}
+
+func TestSearch(t *testing.T) {
+	tagFileName := filepath.Join(t.TempDir(), "tags")
+	assert.NoError(t, os.WriteFile(tagFileName, []byte(strings.Join(tagLines, "\n")+"\n"), 0o644))
+
+	tests := []struct {
+		query         Query
+		expectedLines []string
+	}{
+		{Query{Name: "Foo"}, []string{tagLines[2], tagLines[3]}},
+		{Query{Name: "Foo", Match: MatchPrefix}, []string{tagLines[2], tagLines[3], tagLines[4]}},
+		{Query{Name: "foo", Match: MatchPrefix, IgnoreCase: true}, []string{tagLines[2], tagLines[3], tagLines[4], tagLines[6]}},
+		{Query{Name: "^F.*r$", Match: MatchRegex}, []string{tagLines[4]}},
+		{Query{Name: "Foo", Kind: "f"}, []string{tagLines[3]}},
+		{Query{Name: "Foo", FileName: "./foo.go"}, []string{tagLines[2]}},
+		{Query{Name: "Foo", Match: MatchPrefix, Scope: "struct:main.Foo"}, []string{tagLines[4]}},
+		{Query{Name: "Bar"}, []string{tagLines[1]}},
+		{Query{Name: "foo"}, []string{tagLines[6]}},
+		{Query{Name: "Baz"}, []string{}},
+		{Query{Name: "zzz"}, []string{}},
+	}
+
+	for _, test := range tests {
+		results, err := Search(tagFileName, test.query)
+		assert.NoError(t, err)
+
+		lines := []string{}
+		for _, result := range results {
+			lines = append(lines, result.Line)
+		}
+
+		assert.Equal(t, test.expectedLines, lines, test.query)
+	}
+}
+
+func TestSearchSortModes(t *testing.T) {
+	foldcaseLines := []string{
+		"!_TAG_FILE_SORTED\t2\t/0=unsorted, 1=sorted, 2=foldcase/",
+		"Bar\tbar.go\t/^func Bar() {}$/;\"\tf",
+		"foo\tfoo.go\t/^var foo int$/;\"\tv",
+		"Foo\tfoo.go\t/^type Foo struct {$/;\"\ts",
+		"FooBar\tfoo.go\t/^\tFooBar int$/;\"\tm",
+		"zed\tzed.go\t/^var zed int$/;\"\tv",
+	}
+
+	unsortedLines := []string{
+		"!_TAG_FILE_SORTED\t0\t/0=unsorted, 1=sorted, 2=foldcase/",
+		"Zed\tzed.go\t/^var Zed int$/;\"\tv",
+		"Foo\tfoo.go\t/^type Foo struct {$/;\"\ts",
+		"Bar\tbar.go\t/^func Bar() {}$/;\"\tf",
+		"Foo\tfoo_test.go\t/^func Foo() {}$/;\"\tf",
+	}
+
+	tests := []struct {
+		lines         []string
+		query         Query
+		expectedLines []string
+	}{
+		{foldcaseLines, Query{Name: "Foo"}, []string{foldcaseLines[3]}},
+		{foldcaseLines, Query{Name: "foo"}, []string{foldcaseLines[2]}},
+		{foldcaseLines, Query{Name: "FOO", IgnoreCase: true}, []string{foldcaseLines[2], foldcaseLines[3]}},
+		{foldcaseLines, Query{Name: "foo", Match: MatchPrefix, IgnoreCase: true}, []string{foldcaseLines[2], foldcaseLines[3], foldcaseLines[4]}},
+		{foldcaseLines, Query{Name: "Zed", IgnoreCase: true}, []string{foldcaseLines[5]}},
+		{foldcaseLines, Query{Name: "Baz"}, []string{}},
+		{unsortedLines, Query{Name: "Foo"}, []string{unsortedLines[2], unsortedLines[4]}},
+		{unsortedLines, Query{Name: "Bar"}, []string{unsortedLines[3]}},
+	}
+
+	for _, test := range tests {
+		tagFileName := filepath.Join(t.TempDir(), "tags")
+		assert.NoError(t, os.WriteFile(tagFileName, []byte(strings.Join(test.lines, "\n")+"\n"), 0o644))
+
+		results, err := Search(tagFileName, test.query)
+		assert.NoError(t, err)
+
+		lines := []string{}
+		for _, result := range results {
+			lines = append(lines, result.Line)
+		}
+
+		assert.Equal(t, test.expectedLines, lines, test.query)
+	}
+}
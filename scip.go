@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+	scip "github.com/jha-naman/tree-tags/scip"
+)
+
+// runSCIP implements the 'scip' subcommand, writing a SCIP index of the go
+// files of the module in the current directory.
+func runSCIP(args []string) {
+	flagSet := flag.NewFlagSet("scip", flag.ExitOnError)
+	flagSet.Usage = func() {
+		fmt.Fprintln(flagSet.Output(), "usage: tree-tags scip [options] [FILE|DIR...]")
+		flagSet.PrintDefaults()
+	}
+
+	var outputFileName, version string
+	var fullDoc bool
+	flagSet.StringVar(&outputFileName, "o", "index.scip", "file to write the index to")
+	flagSet.StringVar(&version, "version", "", "version of the module for the symbol names, like a tag or commit")
+	flagSet.BoolVar(&fullDoc, "doc-full", false, "use the full text of doc comments for the documentation of the symbols, instead of the one line summary")
+
+	paths := parseInterspersed(flagSet, subcommandArgs(flagSet, args))
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	modulePath, err := readModulePath("go.mod")
+	if err != nil {
+		log.Fatal("error while reading the module path, run in the root directory of the module:", err.Error())
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatal("error while getting working directory:", err.Error())
+	}
+
+	var fileNames []string
+	for _, fileName := range goFileNames(paths) {
+		relativeFileName, err := relativePath(wd, fileName)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
+
+		fileNames = append(fileNames, relativeFileName)
+	}
+
+	documents, err := golang.SCIPDocuments(modulePath, version, fileNames, common.Options{FullDoc: fullDoc})
+	if err != nil {
+		log.Fatal("error while indexing files:", err.Error())
+	}
+
+	index := scip.Index{
+		ToolName:    "tree-tags",
+		ToolVersion: toolVersion(),
+		ProjectRoot: "file://" + filepath.ToSlash(wd),
+		Documents:   documents,
+	}
+
+	if err = os.WriteFile(outputFileName, index.Marshal(), 0o644); err != nil {
+		log.Fatal("error while writing index:", err.Error())
+	}
+}
+
+// readModulePath returns the path of the module declared in the go.mod file.
+func readModulePath(goModFileName string) (string, error) {
+	file, err := os.Open(goModFileName)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "module" {
+			continue
+		}
+
+		if modulePath, err := strconv.Unquote(fields[1]); err == nil {
+			return modulePath, nil
+		}
+
+		return fields[1], nil
+	}
+
+	if err = scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", errors.New("no module directive in " + goModFileName)
+}
+
+// relativePath returns the path of the file relative to the root directory,
+// which it needs to be in.
+func relativePath(root, fileName string) (string, error) {
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(root, fileName)
+	}
+
+	relativeFileName, err := filepath.Rel(root, fileName)
+	if err != nil || strings.HasPrefix(relativeFileName, "..") {
+		return "", fmt.Errorf("%s is not in the module directory %s", fileName, root)
+	}
+
+	return relativeFileName, nil
+}
+
+// toolVersion returns the version tree-tags was installed with, if known.
+func toolVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+
+	return ""
+}
@@ -0,0 +1,184 @@
+// Package scip writes SCIP indexes (https://github.com/sourcegraph/scip), the
+// code intelligence format read by code search platforms for precise
+// navigation. Only the parts of the format tags can fill are implemented: the
+// documents with their symbols and the occurrences of the symbols, encoded
+// with protowire following the field numbers of scip.proto.
+package scip
+
+import (
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// SymbolRoleDefinition is the role of the occurrences defining a symbol.
+const SymbolRoleDefinition = 1
+
+const (
+	textEncodingUTF8 = 1
+	// positions are byte offsets from the start of the line
+	positionEncodingUTF8 = 1
+)
+
+type Index struct {
+	ToolName    string
+	ToolVersion string
+	// ProjectRoot is the 'file://' URI of the directory the paths of the
+	// documents are relative to.
+	ProjectRoot string
+	Documents   []Document
+}
+
+type Document struct {
+	Language     string
+	RelativePath string
+	Occurrences  []Occurrence
+	Symbols      []SymbolInformation
+}
+
+type Occurrence struct {
+	// Range is the line, the start column and the end column, all 0 based.
+	Range       [3]int32
+	Symbol      string
+	SymbolRoles int32
+}
+
+type SymbolInformation struct {
+	Symbol        string
+	Documentation []string
+	DisplayName   string
+}
+
+// Descriptor is a part of the name of a symbol, like the package, type or
+// method in 'scip-go gomod example.com/m . `example.com/m/server`/Server#Start().'.
+type Descriptor struct {
+	Name string
+	// Suffix is '/' for namespaces, '#' for types, '.' for terms and '().'
+	// for methods.
+	Suffix string
+}
+
+// Symbol returns the symbol string for the descriptors in the package of the
+// package manager, like 'scip-go gomod example.com/m v1.0.0 ...'. Empty parts
+// are written as '.', as the format wants.
+func Symbol(scheme, manager, packageName, version string, descriptors ...Descriptor) string {
+	var symbol strings.Builder
+	for _, part := range []string{scheme, manager, packageName, version} {
+		if part == "" {
+			part = "."
+		}
+
+		symbol.WriteString(strings.ReplaceAll(part, " ", "  "))
+		symbol.WriteByte(' ')
+	}
+
+	for _, descriptor := range descriptors {
+		symbol.WriteString(escapeName(descriptor.Name))
+		symbol.WriteString(descriptor.Suffix)
+	}
+
+	return symbol.String()
+}
+
+// escapeName wraps names with characters other than letters, digits, '_', '+',
+// '-' and '$' in backticks, doubling the backticks in them.
+func escapeName(name string) string {
+	for _, c := range name {
+		if !isSimpleIdentifierCharacter(c) {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+	}
+
+	return name
+}
+
+func isSimpleIdentifierCharacter(c rune) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("_+-$", c)
+}
+
+// Marshal returns the protobuf encoding of the index.
+func (index *Index) Marshal() []byte {
+	var toolInfo []byte
+	toolInfo = appendString(toolInfo, 1, index.ToolName)
+	toolInfo = appendString(toolInfo, 2, index.ToolVersion)
+
+	var metadata []byte
+	metadata = appendBytes(metadata, 2, toolInfo)
+	metadata = appendString(metadata, 3, index.ProjectRoot)
+	metadata = appendVarint(metadata, 4, textEncodingUTF8)
+
+	var b []byte
+	b = appendBytes(b, 1, metadata)
+	for _, document := range index.Documents {
+		b = appendBytes(b, 2, document.marshal())
+	}
+
+	return b
+}
+
+func (document *Document) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, document.RelativePath)
+	for _, occurrence := range document.Occurrences {
+		b = appendBytes(b, 2, occurrence.marshal())
+	}
+
+	for _, symbol := range document.Symbols {
+		b = appendBytes(b, 3, symbol.marshal())
+	}
+
+	b = appendString(b, 4, document.Language)
+	b = appendVarint(b, 6, positionEncodingUTF8)
+
+	return b
+}
+
+func (occurrence *Occurrence) marshal() []byte {
+	var packedRange []byte
+	for _, position := range occurrence.Range {
+		packedRange = protowire.AppendVarint(packedRange, uint64(position))
+	}
+
+	var b []byte
+	b = appendBytes(b, 1, packedRange)
+	b = appendString(b, 2, occurrence.Symbol)
+	b = appendVarint(b, 3, uint64(occurrence.SymbolRoles))
+
+	return b
+}
+
+func (symbol *SymbolInformation) marshal() []byte {
+	var b []byte
+	b = appendString(b, 1, symbol.Symbol)
+	for _, documentation := range symbol.Documentation {
+		b = appendString(b, 3, documentation)
+	}
+	b = appendString(b, 6, symbol.DisplayName)
+
+	return b
+}
+
+// appendString appends the string field, leaving it out when empty like the
+// protobuf encoding of proto3 does.
+func appendString(b []byte, number protowire.Number, value string) []byte {
+	if value == "" {
+		return b
+	}
+
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendBytes(b []byte, number protowire.Number, value []byte) []byte {
+	b = protowire.AppendTag(b, number, protowire.BytesType)
+	return protowire.AppendBytes(b, value)
+}
+
+func appendVarint(b []byte, number protowire.Number, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+
+	b = protowire.AppendTag(b, number, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
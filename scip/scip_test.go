@@ -0,0 +1,66 @@
+package scip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestSymbol(t *testing.T) {
+	symbol := Symbol("scip-go", "gomod", "example.com/m", "", Descriptor{Name: "example.com/m/server", Suffix: "/"}, Descriptor{Name: "Server", Suffix: "#"}, Descriptor{Name: "Start", Suffix: "()."})
+	assert.Equal(t, "scip-go gomod example.com/m . `example.com/m/server`/Server#Start().", symbol)
+
+	assert.Equal(t, "a b  c . . `x``y`.", Symbol("a", "b c", "", "", Descriptor{Name: "x`y", Suffix: "."}))
+}
+
+func TestMarshal(t *testing.T) {
+	index := Index{
+		ToolName: "tree-tags",
+		Documents: []Document{{
+			Language:     "go",
+			RelativePath: "main.go",
+			Occurrences:  []Occurrence{{Range: [3]int32{2, 5, 9}, Symbol: "s", SymbolRoles: SymbolRoleDefinition}},
+			Symbols:      []SymbolInformation{{Symbol: "s", DisplayName: "main"}},
+		}},
+	}
+
+	fields := consumeFields(t, index.Marshal())
+	assert.Len(t, fields[1], 1)
+	assert.Len(t, fields[2], 1)
+
+	document := consumeFields(t, fields[2][0])
+	assert.Equal(t, "main.go", string(document[1][0]))
+	assert.Equal(t, "go", string(document[4][0]))
+
+	occurrence := consumeFields(t, document[2][0])
+	assert.Equal(t, []byte{2, 5, 9}, occurrence[1][0])
+	assert.Equal(t, "s", string(occurrence[2][0]))
+
+	symbol := consumeFields(t, document[3][0])
+	assert.Equal(t, "main", string(symbol[6][0]))
+}
+
+// consumeFields returns the values of the length delimited fields of the
+// message by field number.
+func consumeFields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	fields := map[protowire.Number][][]byte{}
+	for len(b) > 0 {
+		number, fieldType, n := protowire.ConsumeTag(b)
+		assert.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		if fieldType == protowire.BytesType {
+			value, n := protowire.ConsumeBytes(b)
+			fields[number] = append(fields[number], value)
+			b = b[n:]
+			continue
+		}
+
+		n = protowire.ConsumeFieldValue(number, fieldType, b)
+		assert.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+	}
+
+	return fields
+}
@@ -0,0 +1,298 @@
+// Package symboldb writes tags to a sqlite database, for tools running
+// queries like "all exported methods on types in package X" without parsing
+// the tags file format. The database has these tables:
+//
+//	files   (id, path, generated)
+//	scopes  (id, kind, name)
+//	symbols (id, name, lower_name, kind, file_id, line, end_line, pattern,
+//	         scope_id, access, signature, typeref, doc, fields)
+//	refs    (id, name, lower_name, kind, file_id, line, pattern, scope_id)
+//
+// Kinds are given by their long names. The scope kind is 'package', 'struct',
+// 'interface', 'type' for the receiver types of methods or 'func' for the
+// functions references are in. The extension fields without a column of
+// their own are in the 'fields' column, as a json object. Both the symbols
+// and the refs are indexed by name and lowercase name.
+package symboldb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	common "github.com/jha-naman/tree-tags/common"
+
+	// registers the pure go 'sqlite' driver
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE files (
+	id INTEGER PRIMARY KEY,
+	path TEXT NOT NULL UNIQUE,
+	generated INTEGER NOT NULL
+);
+CREATE TABLE scopes (
+	id INTEGER PRIMARY KEY,
+	kind TEXT NOT NULL,
+	name TEXT NOT NULL,
+	UNIQUE (kind, name)
+);
+CREATE TABLE symbols (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	lower_name TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	file_id INTEGER NOT NULL REFERENCES files (id),
+	line INTEGER,
+	end_line INTEGER,
+	pattern TEXT NOT NULL,
+	scope_id INTEGER REFERENCES scopes (id),
+	access TEXT,
+	signature TEXT,
+	typeref TEXT,
+	doc TEXT,
+	fields TEXT NOT NULL
+);
+CREATE TABLE refs (
+	id INTEGER PRIMARY KEY,
+	name TEXT NOT NULL,
+	lower_name TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	file_id INTEGER NOT NULL REFERENCES files (id),
+	line INTEGER,
+	pattern TEXT NOT NULL,
+	scope_id INTEGER REFERENCES scopes (id)
+);
+CREATE INDEX symbols_name ON symbols (name);
+CREATE INDEX symbols_lower_name ON symbols (lower_name);
+CREATE INDEX refs_name ON refs (name);
+CREATE INDEX refs_lower_name ON refs (lower_name);
+`
+
+// scopeFields are the extension fields holding the scope of a tag, with the
+// kinds of scope they are for. Methods have their receiver type in the
+// 'unkown' field, whose scopes are named by the type without the pointer and
+// type parameters.
+var scopeFields = []struct{ key, kind string }{
+	{"struct", "struct"},
+	{"interface", "interface"},
+	{"unkown", "type"},
+	{"func", "func"},
+	{"package", "package"},
+}
+
+// columnFields are the extension fields having columns of their own, besides
+// the scope fields.
+var columnFields = []string{"line", "end", "access", "signature", "typeref:typename", "doc", "roles"}
+
+// Write creates the database at the path, which should not exist or be
+// empty, with the tags and the reference tags. Kinds are written by their
+// long names from the kinds list, and the full doc comments instead of their
+// summaries with fullDoc.
+func Write(path string, tags, references []common.TagEntry, kinds []common.Kind, fullDoc bool) error {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err = db.Exec(schema); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	w := &writer{tx: tx, kinds: kinds, fullDoc: fullDoc, fileIDs: map[string]int64{}, scopeIDs: map[[2]string]int64{}}
+	if err = w.prepare(); err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if err = w.writeSymbol(tag); err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range references {
+		if err = w.writeReference(tag); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	return db.Close()
+}
+
+type writer struct {
+	tx      *sql.Tx
+	kinds   []common.Kind
+	fullDoc bool
+
+	fileIDs  map[string]int64
+	scopeIDs map[[2]string]int64
+
+	insertFile, insertScope, insertSymbol, insertReference *sql.Stmt
+}
+
+func (w *writer) prepare() error {
+	statements := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&w.insertFile, "INSERT INTO files (id, path, generated) VALUES (?, ?, ?)"},
+		{&w.insertScope, "INSERT INTO scopes (id, kind, name) VALUES (?, ?, ?)"},
+		{&w.insertSymbol, "INSERT INTO symbols (name, lower_name, kind, file_id, line, end_line, pattern, scope_id, access, signature, typeref, doc, fields) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"},
+		{&w.insertReference, "INSERT INTO refs (name, lower_name, kind, file_id, line, pattern, scope_id) VALUES (?, ?, ?, ?, ?, ?, ?)"},
+	}
+
+	for _, statement := range statements {
+		stmt, err := w.tx.Prepare(statement.query)
+		if err != nil {
+			return err
+		}
+
+		*statement.stmt = stmt
+	}
+
+	return nil
+}
+
+func (w *writer) writeSymbol(tag common.TagEntry) error {
+	fileID, err := w.fileID(tag)
+	if err != nil {
+		return err
+	}
+
+	scopeID, scopeKey, err := w.scopeID(tag)
+	if err != nil {
+		return err
+	}
+
+	doc := tag.ExtensionFields["doc"]
+	if w.fullDoc && tag.Doc != "" {
+		doc = tag.Doc
+	}
+
+	fields := map[string]string{}
+	for key, value := range tag.ExtensionFields {
+		if key != scopeKey && !slices.Contains(columnFields, key) {
+			fields[key] = value
+		}
+	}
+
+	fieldsJSON, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.insertSymbol.Exec(
+		tag.Name, strings.ToLower(tag.Name), common.KindName(w.kinds, tag.Kind), fileID,
+		nullableInt(tag.ExtensionFields["line"]), nullableInt(tag.ExtensionFields["end"]), pattern(tag), scopeID,
+		nullableString(tag.ExtensionFields["access"]), nullableString(tag.ExtensionFields["signature"]),
+		nullableString(tag.ExtensionFields["typeref:typename"]), nullableString(doc), string(fieldsJSON),
+	)
+
+	return err
+}
+
+func (w *writer) writeReference(tag common.TagEntry) error {
+	fileID, err := w.fileID(tag)
+	if err != nil {
+		return err
+	}
+
+	scopeID, _, err := w.scopeID(tag)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.insertReference.Exec(
+		tag.Name, strings.ToLower(tag.Name), common.KindName(w.kinds, tag.Kind), fileID,
+		nullableInt(tag.ExtensionFields["line"]), pattern(tag), scopeID,
+	)
+
+	return err
+}
+
+// fileID returns the id of the file of the tag, adding it to the files table
+// for its first tag.
+func (w *writer) fileID(tag common.TagEntry) (int64, error) {
+	if id, ok := w.fileIDs[tag.FileName]; ok {
+		return id, nil
+	}
+
+	id := int64(len(w.fileIDs) + 1)
+	generated := tag.ExtensionFields["generated"] == "yes"
+	if _, err := w.insertFile.Exec(id, tag.FileName, generated); err != nil {
+		return 0, err
+	}
+
+	w.fileIDs[tag.FileName] = id
+
+	return id, nil
+}
+
+// scopeID returns the id of the scope of the tag, nil for tags without one,
+// and the extension field the scope is from.
+func (w *writer) scopeID(tag common.TagEntry) (any, string, error) {
+	for _, field := range scopeFields {
+		name, ok := tag.ExtensionFields[field.key]
+		if !ok {
+			continue
+		}
+
+		// methods with pointer and value receivers, like 'main.*Server' and
+		// 'main.Server', and on generic types, like 'main.G[T]', share the
+		// scope of their type
+		if field.key == "unkown" {
+			name = common.ReceiverTypeName(name)
+		}
+
+		scope := [2]string{field.kind, name}
+		if id, ok := w.scopeIDs[scope]; ok {
+			return id, field.key, nil
+		}
+
+		id := int64(len(w.scopeIDs) + 1)
+		if _, err := w.insertScope.Exec(id, field.kind, name); err != nil {
+			return nil, "", err
+		}
+
+		w.scopeIDs[scope] = id
+
+		return id, field.key, nil
+	}
+
+	return nil, "", nil
+}
+
+func pattern(tag common.TagEntry) string {
+	return strings.TrimSuffix(tag.Address, ";\"")
+}
+
+func nullableInt(value string) any {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return nil
+	}
+
+	return number
+}
+
+func nullableString(value string) any {
+	if value == "" {
+		return nil
+	}
+
+	return value
+}
@@ -0,0 +1,80 @@
+package symboldb
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	common "github.com/jha-naman/tree-tags/common"
+	golang "github.com/jha-naman/tree-tags/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	input := `package main
+
+// Server serves.
+type Server struct{}
+
+func (s *Server) Start() {
+	listen()
+}
+
+func listen() {}
+
+func (s Server) Stop() {}
+`
+
+	p := golang.NewProcessorFromBytes("main.go", []byte(input), common.Options{References: true})
+
+	var tags, references []common.TagEntry
+	for _, tag := range p.GetTags() {
+		if tag.ExtensionFields["roles"] == "ref" {
+			references = append(references, tag)
+		} else {
+			tags = append(tags, tag)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "tags.db")
+	assert.NoError(t, Write(path, tags, references, golang.Kinds, false))
+
+	db, err := sql.Open("sqlite", path)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`
+SELECT symbols.name, symbols.kind, symbols.line, scopes.kind, scopes.name, files.path
+FROM symbols JOIN scopes ON symbols.scope_id = scopes.id JOIN files ON symbols.file_id = files.id
+WHERE symbols.access = 'public' ORDER BY symbols.line`)
+	assert.NoError(t, err)
+	defer rows.Close()
+
+	var found [][6]any
+	for rows.Next() {
+		var name, kind, scopeKind, scopeName, path string
+		var line int
+		assert.NoError(t, rows.Scan(&name, &kind, &line, &scopeKind, &scopeName, &path))
+		found = append(found, [6]any{name, kind, line, scopeKind, scopeName, path})
+	}
+
+	assert.Equal(t, [][6]any{
+		{"Server", "struct", 4, "package", "main", "main.go"},
+		{"Start", "func", 6, "type", "main.Server", "main.go"},
+		{"Stop", "func", 12, "type", "main.Server", "main.go"},
+	}, found)
+
+	var typeScopes int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM scopes WHERE kind = 'type'").Scan(&typeScopes))
+	assert.Equal(t, 1, typeScopes)
+
+	var doc, fields string
+	assert.NoError(t, db.QueryRow("SELECT doc, fields FROM symbols WHERE lower_name = 'server'").Scan(&doc, &fields))
+	assert.Equal(t, "Server serves.", doc)
+	assert.Equal(t, "{}", fields)
+
+	var reference, scope string
+	assert.NoError(t, db.QueryRow("SELECT refs.name, scopes.name FROM refs JOIN scopes ON refs.scope_id = scopes.id").Scan(&reference, &scope))
+	assert.Equal(t, "listen", reference)
+	assert.Equal(t, "main.Server.Start", scope)
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package tagfile
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock takes an exclusive advisory lock on the lock file of the given tags
+// file, blocking until concurrent invocations release it. A separate lock file
+// is used since the tags file itself is replaced on every write. The lock file
+// is left in place, removing it would let a waiting invocation lock the
+// removed file while a new one locks a new file.
+func Lock(fileName string) (unlock func(), err error) {
+	lockFile, err := os.OpenFile(lockFileName(fileName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		lockFile.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(lockFile.Fd()), syscall.LOCK_UN)
+		lockFile.Close()
+	}, nil
+}
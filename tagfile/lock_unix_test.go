@@ -0,0 +1,41 @@
+//go:build unix
+
+package tagfile
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "tags")
+
+	unlock, err := Lock(fileName)
+	assert.NoError(t, err)
+	assert.FileExists(t, lockFileName(fileName))
+
+	locked := make(chan func())
+	go func() {
+		unlockSecond, err := Lock(fileName)
+		assert.NoError(t, err)
+		locked <- unlockSecond
+	}()
+
+	select {
+	case <-locked:
+		t.Fatal("the second lock was taken while the first one was held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	unlock()
+
+	select {
+	case unlockSecond := <-locked:
+		unlockSecond()
+	case <-time.After(5 * time.Second):
+		t.Fatal("the second lock was not taken after the first one was released")
+	}
+}
@@ -0,0 +1,46 @@
+// Package tagfile writes tags files so that concurrent readers and writers do
+// not see or produce partially written files.
+package tagfile
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// lockFileName returns the name of the lock file of a tags file.
+func lockFileName(fileName string) string {
+	return fileName + ".lock"
+}
+
+// Write creates a temporary file next to the given file, calls write with it
+// and renames it into place, so that readers never see a partially written
+// file. The file keeps the permissions of the file it replaces. The temporary
+// file is removed when write fails.
+func Write(fileName string, write func(file *os.File) error) error {
+	file, err := os.CreateTemp(filepath.Dir(fileName), "."+filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err = write(file); err != nil {
+		return err
+	}
+
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(fileName); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err = file.Chmod(mode); err != nil {
+		return err
+	}
+
+	if err = file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), fileName)
+}
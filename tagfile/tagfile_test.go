@@ -0,0 +1,55 @@
+package tagfile
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "tags")
+	assert.NoError(t, os.WriteFile(fileName, []byte("old\n"), 0o600))
+
+	assert.NoError(t, Write(fileName, func(file *os.File) error {
+		_, err := io.WriteString(file, "new\n")
+		return err
+	}))
+
+	content, err := os.ReadFile(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, "new\n", string(content))
+
+	info, err := os.Stat(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(fileName))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
+
+func TestWriteError(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "tags")
+	assert.NoError(t, os.WriteFile(fileName, []byte("old\n"), 0o644))
+
+	writeErr := errors.New("write failed")
+	assert.ErrorIs(t, Write(fileName, func(file *os.File) error {
+		if _, err := io.WriteString(file, "partial"); err != nil {
+			return err
+		}
+		return writeErr
+	}), writeErr)
+
+	// the old file is left as it was and the temporary file is removed
+	content, err := os.ReadFile(fileName)
+	assert.NoError(t, err)
+	assert.Equal(t, "old\n", string(content))
+
+	entries, err := os.ReadDir(filepath.Dir(fileName))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,163 @@
+// Package tagquery finds definitions in syntax trees with tree-sitter tags
+// queries (.scm files), following the capture names of the tree-sitter
+// tagging used by GitHub: the defining node is captured as
+// '@definition.<kind>' and its name as '@name', e.g.
+//
+//	(function_declaration name: (identifier) @name) @definition.func
+//
+// An optional '@doc' capture marks the comment documenting the definition.
+// References queries capture uses the same way, as '@reference.<kind>' with
+// their '@name', e.g.
+//
+//	(call_expression function: (identifier) @name) @reference.call
+package tagquery
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+const (
+	definitionCapturePrefix = "definition."
+	referenceCapturePrefix  = "reference."
+)
+
+// Definition is a definition matched by a tags query.
+type Definition struct {
+	// Kind is the part of the '@definition.<kind>' capture name after the dot.
+	Kind string
+	// Node is the node captured as '@definition.<kind>'.
+	Node *sitter.Node
+	// Name is the node captured as '@name'.
+	Name *sitter.Node
+	// Doc is the node captured as '@doc', nil when there is none.
+	Doc *sitter.Node
+	// Pattern is the index of the query pattern that matched.
+	Pattern int
+}
+
+// Reference is a reference matched by a references query.
+type Reference struct {
+	// Kind is the part of the '@reference.<kind>' capture name after the dot.
+	Kind string
+	// Node is the node captured as '@reference.<kind>'.
+	Node *sitter.Node
+	// Name is the node captured as '@name'.
+	Name *sitter.Node
+}
+
+type Query struct {
+	query *sitter.Query
+	// capturePrefix is the prefix of the names of the captures of the
+	// definitions or references the query finds
+	capturePrefix string
+}
+
+// NewQuery compiles the tags query for the language. Queries without any
+// '@definition.<kind>' or '@name' capture are an error, as they cannot find
+// any definition.
+func NewQuery(source []byte, language *sitter.Language) (*Query, error) {
+	return newQuery(source, language, definitionCapturePrefix)
+}
+
+// NewReferencesQuery compiles the references query for the language. Queries
+// without any '@reference.<kind>' or '@name' capture are an error.
+func NewReferencesQuery(source []byte, language *sitter.Language) (*Query, error) {
+	return newQuery(source, language, referenceCapturePrefix)
+}
+
+func newQuery(source []byte, language *sitter.Language, capturePrefix string) (*Query, error) {
+	query, err := sitter.NewQuery(source, language)
+	if err != nil {
+		return nil, err
+	}
+
+	var hasKind, hasName bool
+	for i := uint32(0); i < query.CaptureCount(); i++ {
+		name := query.CaptureNameForId(i)
+		hasKind = hasKind || strings.HasPrefix(name, capturePrefix)
+		hasName = hasName || name == "name"
+	}
+
+	if !hasKind || !hasName {
+		return nil, fmt.Errorf("query needs '@%s<kind>' and '@name' captures", capturePrefix)
+	}
+
+	return &Query{query: query, capturePrefix: capturePrefix}, nil
+}
+
+// References returns the references matched in the tree of the node, in the
+// same order and with the same handling of overlapping patterns as the
+// definitions returned by Definitions.
+func (q *Query) References(node *sitter.Node, source []byte) []Reference {
+	definitions := q.Definitions(node, source)
+
+	references := make([]Reference, 0, len(definitions))
+	for _, definition := range definitions {
+		references = append(references, Reference{Kind: definition.Kind, Node: definition.Node, Name: definition.Name})
+	}
+
+	return references
+}
+
+// Definitions returns the definitions matched in the tree, ordered by the
+// position of their names. When several patterns match the same name node,
+// only the definition of the pattern coming first in the query is kept, so
+// that specific patterns can precede catch-all ones.
+func (q *Query) Definitions(root *sitter.Node, source []byte) []Definition {
+	cursor := sitter.NewQueryCursor()
+	defer cursor.Close()
+
+	cursor.Exec(q.query, root)
+
+	byName := map[uint32]Definition{}
+	for {
+		match, ok := cursor.NextMatch()
+		if !ok {
+			break
+		}
+
+		match = cursor.FilterPredicates(match, source)
+		definition, ok := q.definitionOf(match)
+		if !ok {
+			continue
+		}
+
+		nameStart := definition.Name.StartByte()
+		if existing, found := byName[nameStart]; !found || definition.Pattern < existing.Pattern {
+			byName[nameStart] = definition
+		}
+	}
+
+	definitions := make([]Definition, 0, len(byName))
+	for _, definition := range byName {
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name.StartByte() < definitions[j].Name.StartByte()
+	})
+
+	return definitions
+}
+
+func (q *Query) definitionOf(match *sitter.QueryMatch) (Definition, bool) {
+	definition := Definition{Pattern: int(match.PatternIndex)}
+
+	for _, capture := range match.Captures {
+		switch name := q.query.CaptureNameForId(capture.Index); {
+		case name == "name":
+			definition.Name = capture.Node
+		case name == "doc":
+			definition.Doc = capture.Node
+		case strings.HasPrefix(name, q.capturePrefix):
+			definition.Kind = strings.TrimPrefix(name, q.capturePrefix)
+			definition.Node = capture.Node
+		}
+	}
+
+	return definition, definition.Name != nil && definition.Node != nil && definition.Kind != ""
+}
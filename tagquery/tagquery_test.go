@@ -0,0 +1,64 @@
+package tagquery
+
+import (
+	"testing"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefinitions(t *testing.T) {
+	source := []byte("package main\n\ntype S struct{}\n\ntype N int\n\nfunc f() {}\n")
+	query, err := NewQuery([]byte(`
+(type_spec name: (type_identifier) @name type: (struct_type)) @definition.struct
+(type_spec name: (type_identifier) @name) @definition.type
+((function_declaration name: (identifier) @name) @definition.func
+  (#match? @name "^[a-z]"))
+(function_declaration name: (identifier) @name (#eq? @name "g")) @definition.never`), golang.GetLanguage())
+	assert.NoError(t, err)
+
+	root := sitter.Parse(source, golang.GetLanguage())
+
+	var found [][2]string
+	for _, definition := range query.Definitions(root, source) {
+		found = append(found, [2]string{definition.Kind, definition.Name.Content(source)})
+	}
+
+	assert.Equal(t, [][2]string{{"struct", "S"}, {"type", "N"}, {"func", "f"}}, found)
+}
+
+func TestNewQueryErrors(t *testing.T) {
+	queries := []string{
+		`(function_declaration name: (identifier) @name`,
+		`(function_declaration name: (identifier) @name)`,
+		`(function_declaration) @definition.func`,
+		`(no_such_node) @definition.func`,
+	}
+
+	for _, source := range queries {
+		_, err := NewQuery([]byte(source), golang.GetLanguage())
+		assert.Error(t, err, source)
+	}
+}
+
+func TestReferences(t *testing.T) {
+	source := []byte("package main\n\nfunc f() { g(x) }\n")
+	query, err := NewReferencesQuery([]byte(`
+(call_expression function: (identifier) @name) @reference.call
+(identifier) @name @reference.identifier`), golang.GetLanguage())
+	assert.NoError(t, err)
+
+	root := sitter.Parse(source, golang.GetLanguage())
+	body := root.NamedChild(1).ChildByFieldName("body")
+
+	var found [][2]string
+	for _, reference := range query.References(body, source) {
+		found = append(found, [2]string{reference.Kind, reference.Name.Content(source)})
+	}
+
+	assert.Equal(t, [][2]string{{"call", "g"}, {"identifier", "x"}}, found)
+
+	_, err = NewReferencesQuery([]byte(`(identifier) @name @definition.var`), golang.GetLanguage())
+	assert.Error(t, err)
+}